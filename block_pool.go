@@ -42,6 +42,9 @@ type BlockPool struct {
 	ChainLength, BlocksProcessed int
 
 	peer *Peer
+
+	// Set while the chain is fast synced, see Ethereum.FastSync
+	fastSync *fastSync
 }
 
 func NewBlockPool(eth *Ethereum) *BlockPool {
@@ -122,6 +125,11 @@ func (self *BlockPool) AddHash(hash []byte, peer *Peer) {
 	self.mut.Lock()
 	defer self.mut.Unlock()
 
+	// Known bad blocks are never fetched again
+	if self.eth.ChainManager().IsBadBlock(hash) {
+		return
+	}
+
 	if self.pool[string(hash)] == nil {
 		self.pool[string(hash)] = &block{peer, nil, nil, time.Now(), 0}
 
@@ -143,6 +151,11 @@ func (self *BlockPool) addBlock(b *types.Block, peer *Peer, newBlock bool) {
 
 	hash := string(b.Hash())
 
+	if self.eth.ChainManager().IsBadBlock(b.Hash()) {
+		poollogger.Infof("Ignoring bad block (%x...)\n", hash[0:4])
+		return
+	}
+
 	if self.pool[hash] == nil && !self.eth.ChainManager().HasBlock(b.Hash()) {
 		poollogger.Infof("Got unrequested block (%x...)\n", hash[0:4])
 
@@ -295,6 +308,12 @@ out:
 		case <-self.quit:
 			break out
 		case <-procTimer.C:
+			// Blocks above the fast sync pivot are processed once its state is complete
+			if self.fastSync != nil && self.fastSync.Syncing() {
+				self.fastSync.Update()
+				continue
+			}
+
 			blocks := self.Blocks()
 			types.BlockBy(types.Number).Sort(blocks)
 
@@ -325,7 +344,12 @@ out:
 			if len(blocks) > 0 {
 				chainman := self.eth.ChainManager()
 
-				err := chainman.InsertChain(blocks)
+				var err error
+				if self.fastSync != nil && self.fastSync.Active() {
+					blocks, err = self.insertFast(blocks)
+				} else {
+					err = chainman.InsertChain(blocks)
+				}
 				if err != nil {
 					poollogger.Debugln(err)
 
@@ -349,3 +373,44 @@ out:
 		}
 	}
 }
+
+// Stores the chain without processing it while fast syncing, keeping the last fastSyncPivotDistance blocks
+// of the chain being downloaded in the pool. Once all hashes are fetched the last stored block becomes the
+// pivot whose state is downloaded. Returns the stored blocks.
+func (self *BlockPool) insertFast(blocks types.Blocks) (types.Blocks, error) {
+	chainman := self.eth.ChainManager()
+
+	// Pool entries above the chain, which are still being downloaded
+	remaining := self.Len() - len(blocks)
+
+	n := len(blocks)
+	if remaining < fastSyncPivotDistance {
+		n -= fastSyncPivotDistance - remaining
+	}
+
+	var pivot *types.Block
+	if n > 0 {
+		if err := chainman.InsertFastChain(blocks[:n]); err != nil {
+			return blocks[:n], err
+		}
+		pivot = blocks[n-1]
+	} else {
+		n = 0
+		pivot = chainman.GetBlock(blocks[0].PrevHash)
+	}
+
+	if self.fetchingHashes || remaining >= fastSyncPivotDistance {
+		return blocks[:n], nil
+	}
+
+	if pivot.Number.Cmp(chainman.CurrentBlock().Number) <= 0 {
+		// Nothing was stored without processing, the chain is too short to be worth syncing fast
+		poollogger.Infoln("Chain too short for fast sync, processing blocks")
+		self.fastSync.Stop()
+
+		return nil, nil
+	}
+	self.fastSync.SetPivot(pivot)
+
+	return blocks[:n], nil
+}
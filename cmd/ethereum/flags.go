@@ -25,6 +25,7 @@ import (
 	"os/user"
 	"path"
 
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/vm"
 )
@@ -41,6 +42,7 @@ var (
 	UseUPnP         bool
 	OutboundPort    string
 	ShowGenesis     bool
+	GenesisFile     string
 	AddPeer         string
 	MaxPeer         int
 	GenAddr         bool
@@ -57,11 +59,24 @@ var (
 	DumpHash        string
 	DumpNumber      int
 	VmType          int
+	DbCodec         string
+	MigrateDb       bool
+	UpgradeDb       bool
+	PruneKeep       int
+	RewindTo        int
+	ExportChain     string
+	ImportChain     string
+	FastSync        bool
+	MinGasPrice     string
+	PriceBump       int
+	TxPoolSlots     int
+	TxAccountSlots  int
 )
 
 // flags specific to cli client
 var (
 	StartMining    bool
+	InstantSeal    bool
 	StartJsConsole bool
 	InputFile      string
 )
@@ -102,12 +117,26 @@ func Init() {
 	flag.BoolVar(&DiffTool, "difftool", false, "creates output for diff'ing. Sets LogLevel=0")
 	flag.StringVar(&DiffType, "diff", "all", "sets the level of diff output [vm, all]. Has no effect if difftool=false")
 	flag.BoolVar(&ShowGenesis, "genesis", false, "Dump the genesis block")
+	flag.StringVar(&GenesisFile, "genesisfile", "", "JSON file describing a custom genesis block (default: built-in genesis)")
+	flag.StringVar(&DbCodec, "dbcodec", "rle", "compression of database values: none|rle|snappy (rle)")
+	flag.BoolVar(&MigrateDb, "migratedb", false, "re-encode the database with the codec given by -dbcodec and exit")
+	flag.BoolVar(&UpgradeDb, "db-upgrade", false, "upgrade the database to the current schema version, report the changes and exit")
+	flag.IntVar(&RewindTo, "rewind", -1, "rewind the blockchain to block number n and exit (-1 = don't rewind)")
+	flag.IntVar(&PruneKeep, "prune", 0, "delete state not needed by the last n blocks and exit (0 = don't prune)")
+	flag.StringVar(&ExportChain, "exportchain", "", "export the blockchain to the file given and exit")
+	flag.StringVar(&ImportChain, "importchain", "", "import a blockchain exported with -exportchain and exit")
+	flag.BoolVar(&FastSync, "fastsync", false, "download the state of a recent block instead of processing the whole chain (empty chain only)")
+	flag.StringVar(&MinGasPrice, "mingasprice", core.MinGasPrice.String(), "minimum gas price (wei) of transactions accepted into the pool")
+	flag.IntVar(&PriceBump, "pricebump", core.DefaultPriceBump, "percentage by which a transaction must outbid a pooled one with the same nonce to replace it")
+	flag.IntVar(&TxPoolSlots, "txpoolslots", core.DefaultGlobalSlots, "maximum number of transactions in the pool")
+	flag.IntVar(&TxAccountSlots, "txaccountslots", core.DefaultAccountSlots, "maximum number of transactions of a single sender in the pool")
 
 	flag.BoolVar(&Dump, "dump", false, "output the ethereum state in JSON format. Sub args [number, hash]")
 	flag.StringVar(&DumpHash, "hash", "", "specify arg in hex")
 	flag.IntVar(&DumpNumber, "number", -1, "specify arg in number")
 
 	flag.BoolVar(&StartMining, "mine", false, "start dagger mining")
+	flag.BoolVar(&InstantSeal, "instantseal", false, "seal blocks without proof of work (local development chains only)")
 	flag.BoolVar(&StartJsConsole, "js", false, "launches javascript console")
 
 	flag.Parse()
@@ -23,6 +23,7 @@ import (
 	"runtime"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/cmd/utils"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
@@ -56,7 +57,11 @@ func main() {
 
 	utils.InitLogging(Datadir, LogFile, LogLevel, DebugFile)
 
-	db := utils.NewDatabase()
+	if MigrateDb {
+		utils.MigrateDatabase(DbCodec)
+	}
+
+	db := utils.NewDatabaseWithCodec(DbCodec)
 	err := utils.DBSanityCheck(db)
 	if err != nil {
 		fmt.Println(err)
@@ -64,6 +69,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	err = utils.UpgradeDatabase(db)
+	if err != nil {
+		fmt.Println(err)
+
+		os.Exit(1)
+	}
+
+	if UpgradeDb {
+		db.Close()
+		logger.Flush()
+		os.Exit(0)
+	}
+
 	keyManager := utils.NewKeyManager(KeyStore, Datadir, db)
 
 	// create, import, export keys
@@ -71,7 +89,13 @@ func main() {
 
 	clientIdentity := utils.NewClientIdentity(ClientIdentifier, Version, Identifier)
 
-	ethereum := utils.NewEthereum(db, clientIdentity, keyManager, UseUPnP, OutboundPort, MaxPeer)
+	ethereum := utils.NewEthereum(db, clientIdentity, keyManager, UseUPnP, OutboundPort, MaxPeer, GenesisFile)
+
+	if InstantSeal {
+		ethereum.BlockManager().Engine = core.NewInstantSealEngine()
+	}
+	ethereum.FastSync = FastSync
+	utils.ConfigureTxPool(ethereum, MinGasPrice, PriceBump, TxPoolSlots, TxAccountSlots)
 
 	if Dump {
 		var block *types.Block
@@ -108,6 +132,22 @@ func main() {
 		utils.ShowGenesis(ethereum)
 	}
 
+	if RewindTo >= 0 {
+		utils.RewindChain(ethereum, uint64(RewindTo))
+	}
+
+	if PruneKeep > 0 {
+		utils.PruneState(ethereum, uint64(PruneKeep))
+	}
+
+	if len(ExportChain) > 0 {
+		utils.ExportChain(ethereum, ExportChain)
+	}
+
+	if len(ImportChain) > 0 {
+		utils.ImportChain(ethereum, ImportChain)
+	}
+
 	if StartMining {
 		utils.StartMining(ethereum)
 	}
@@ -28,6 +28,7 @@ import (
 	"runtime"
 
 	"bitbucket.org/kardianos/osext"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/vm"
 )
@@ -42,6 +43,12 @@ var (
 	UseUPnP         bool
 	OutboundPort    string
 	ShowGenesis     bool
+	GenesisFile     string
+	FastSync        bool
+	MinGasPrice     string
+	PriceBump       int
+	TxPoolSlots     int
+	TxAccountSlots  int
 	AddPeer         string
 	MaxPeer         int
 	GenAddr         bool
@@ -118,6 +125,12 @@ func Init() {
 	flag.StringVar(&ConfigFile, "conf", defaultConfigFile, "config file")
 	flag.StringVar(&DebugFile, "debug", "", "debug file (no debugging if not set)")
 	flag.IntVar(&LogLevel, "loglevel", int(logger.InfoLevel), "loglevel: 0-5: silent,error,warn,info,debug,debug detail)")
+	flag.StringVar(&GenesisFile, "genesisfile", "", "JSON file describing a custom genesis block (default: built-in genesis)")
+	flag.BoolVar(&FastSync, "fastsync", false, "download the state of a recent block instead of processing the whole chain (empty chain only)")
+	flag.StringVar(&MinGasPrice, "mingasprice", core.MinGasPrice.String(), "minimum gas price (wei) of transactions accepted into the pool")
+	flag.IntVar(&PriceBump, "pricebump", core.DefaultPriceBump, "percentage by which a transaction must outbid a pooled one with the same nonce to replace it")
+	flag.IntVar(&TxPoolSlots, "txpoolslots", core.DefaultGlobalSlots, "maximum number of transactions in the pool")
+	flag.IntVar(&TxAccountSlots, "txaccountslots", core.DefaultAccountSlots, "maximum number of transactions of a single sender in the pool")
 
 	flag.StringVar(&AssetPath, "asset_path", defaultAssetPath(), "absolute path to GUI assets directory")
 
@@ -339,7 +339,7 @@ func (gui *Gui) insertTransaction(window string, tx *types.Transaction) {
 }
 
 func (gui *Gui) readPreviousTransactions() {
-	it := gui.txDb.NewIterator()
+	it := gui.txDb.NewIterator(nil)
 	for it.Next() {
 		tx := types.NewTransactionFromBytes(it.Value())
 
@@ -470,7 +470,7 @@ func (gui *Gui) update() {
 			case <-generalUpdateTicker.C:
 				statusText := "#" + gui.eth.ChainManager().CurrentBlock().Number.String()
 				lastBlockLabel.Set("text", statusText)
-				miningLabel.Set("text", "Mining @ "+strconv.FormatInt(gui.uiLib.miner.GetPow().GetHashrate(), 10)+"Khash")
+				miningLabel.Set("text", "Mining @ "+strconv.FormatInt(gui.uiLib.miner.HashRate(), 10)+"Khash")
 
 				blockLength := gui.eth.BlockPool().BlocksProcessed
 				chainLength := gui.eth.BlockPool().ChainLength
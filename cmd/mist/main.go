@@ -54,12 +54,21 @@ func run() error {
 
 		os.Exit(1)
 	}
+	err = utils.UpgradeDatabase(db)
+	if err != nil {
+		ErrorWindow(err)
+
+		os.Exit(1)
+	}
+
 	keyManager := utils.NewKeyManager(KeyStore, Datadir, db)
 
 	// create, import, export keys
 	utils.KeyTasks(keyManager, KeyRing, GenAddr, SecretFile, ExportDir, NonInteractive)
 	clientIdentity := utils.NewClientIdentity(ClientIdentifier, Version, Identifier)
-	ethereum = utils.NewEthereum(db, clientIdentity, keyManager, UseUPnP, OutboundPort, MaxPeer)
+	ethereum = utils.NewEthereum(db, clientIdentity, keyManager, UseUPnP, OutboundPort, MaxPeer, GenesisFile)
+	ethereum.FastSync = FastSync
+	utils.ConfigureTxPool(ethereum, MinGasPrice, PriceBump, TxPoolSlots, TxAccountSlots)
 
 	if ShowGenesis {
 		utils.ShowGenesis(ethereum)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math/big"
 	"os"
 	"os/signal"
 	"path"
@@ -14,6 +15,7 @@ import (
 
 	"bitbucket.org/kardianos/osext"
 	"github.com/georzaza/go-ethereum-v0.7.10_official"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
@@ -87,6 +89,26 @@ func DBSanityCheck(db ethutil.Database) error {
 		return fmt.Errorf("Database version mismatch. Protocol(%d / %d). `rm -rf %s`", protov, eth.ProtocolVersion, ethutil.Config.ExecPath+"/database")
 	}
 
+	return ethdb.CheckSchema(db)
+}
+
+// Applies pending schema migrations to the database and reports them
+func UpgradeDatabase(db ethutil.Database) error {
+	from := ethdb.GetSchemaVersion(db)
+	results, err := ethdb.UpgradeSchema(db)
+	for _, r := range results {
+		clilogger.Infof("Schema version %d: %s (%d entries changed)\n", r.Version, r.Description, r.Changed)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(results) > 0 {
+		clilogger.Infof("Upgraded database from schema version %d to %d\n", from, ethdb.SchemaVersion())
+	} else {
+		clilogger.Infof("Database is up to date (schema version %d)\n", ethdb.SchemaVersion())
+	}
+
 	return nil
 }
 
@@ -137,19 +159,41 @@ func exit(err error) {
 }
 
 func NewDatabase() ethutil.Database {
-	db, err := ethdb.NewLDBDatabase("database")
+	return NewDatabaseWithCodec(ethdb.DefaultCodec)
+}
+
+func NewDatabaseWithCodec(codec string) ethutil.Database {
+	db, err := ethdb.NewLDBDatabaseWithCodec("database", codec)
 	if err != nil {
 		exit(err)
 	}
 	return db
 }
 
+// Re-encodes all values of the database with the given codec and exits
+func MigrateDatabase(codec string) {
+	clilogger.Infof("Migrating database to codec '%s'\n", codec)
+	count, err := ethdb.MigrateCodec("database", codec)
+	if err == nil {
+		clilogger.Infof("Migrated %d values\n", count)
+	}
+	exit(err)
+}
+
 func NewClientIdentity(clientIdentifier, version, customIdentifier string) *wire.SimpleClientIdentity {
 	return wire.NewSimpleClientIdentity(clientIdentifier, version, customIdentifier)
 }
 
-func NewEthereum(db ethutil.Database, clientIdentity wire.ClientIdentity, keyManager *crypto.KeyManager, usePnp bool, OutboundPort string, MaxPeer int) *eth.Ethereum {
-	ethereum, err := eth.New(db, clientIdentity, keyManager, eth.CapDefault, usePnp)
+func NewEthereum(db ethutil.Database, clientIdentity wire.ClientIdentity, keyManager *crypto.KeyManager, usePnp bool, OutboundPort string, MaxPeer int, GenesisFile string) *eth.Ethereum {
+	var genesis *core.GenesisSpec
+	if len(GenesisFile) > 0 {
+		var err error
+		if genesis, err = core.LoadGenesis(GenesisFile); err != nil {
+			clilogger.Fatalln("genesis file err:", err)
+		}
+	}
+
+	ethereum, err := eth.New(db, clientIdentity, keyManager, eth.CapDefault, usePnp, genesis)
 	if err != nil {
 		clilogger.Fatalln("eth start err:", err)
 	}
@@ -158,6 +202,23 @@ func NewEthereum(db ethutil.Database, clientIdentity wire.ClientIdentity, keyMan
 	return ethereum
 }
 
+// Applies the transaction pool settings. minGasPrice is a decimal number of wei.
+func ConfigureTxPool(ethereum *eth.Ethereum, minGasPrice string, priceBump, globalSlots, accountSlots int) {
+	price, ok := new(big.Int).SetString(minGasPrice, 10)
+	if !ok || price.Sign() < 0 {
+		clilogger.Fatalln("invalid minimum gas price:", minGasPrice)
+	}
+	if priceBump < 0 || globalSlots < 1 || accountSlots < 1 {
+		clilogger.Fatalln("invalid transaction pool limits")
+	}
+
+	txPool := ethereum.TxPool()
+	txPool.MinGasPrice = price
+	txPool.PriceBump = uint64(priceBump)
+	txPool.GlobalSlots = globalSlots
+	txPool.AccountSlots = accountSlots
+}
+
 func StartEthereum(ethereum *eth.Ethereum, UseSeed bool) {
 	clilogger.Infof("Starting %s", ethereum.ClientIdentity())
 	ethereum.Start(UseSeed)
@@ -172,6 +233,57 @@ func ShowGenesis(ethereum *eth.Ethereum) {
 	exit(nil)
 }
 
+// Rewinds the blockchain to the given block number and exits
+func RewindChain(ethereum *eth.Ethereum, number uint64) {
+	chainMan := ethereum.ChainManager()
+	clilogger.Infof("Rewinding blockchain from #%v to #%d\n", chainMan.CurrentBlock().Number, number)
+	exit(chainMan.SetHead(number))
+}
+
+// Deletes the state not needed by the last keep blocks and exits
+func PruneState(ethereum *eth.Ethereum, keep uint64) {
+	clilogger.Infof("Pruning state older than %d blocks\n", keep)
+	count, err := ethereum.ChainManager().PruneState(keep)
+	if err == nil {
+		clilogger.Infof("Deleted %d entries\n", count)
+	}
+	exit(err)
+}
+
+// Writes the whole blockchain to the given file and exits
+func ExportChain(ethereum *eth.Ethereum, fn string) {
+	fh, err := os.OpenFile(ethutil.ExpandHomePath(fn), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		exit(err)
+	}
+
+	chainMan := ethereum.ChainManager()
+	err = chainMan.ExportRange(fh, 0, chainMan.CurrentBlock().Number.Uint64())
+	if cerr := fh.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		clilogger.Infof("Exported blockchain to '%s'\n", fn)
+	}
+	exit(err)
+}
+
+// Inserts the blocks of a file written by ExportChain and exits
+func ImportChain(ethereum *eth.Ethereum, fn string) {
+	fh, err := os.Open(ethutil.ExpandHomePath(fn))
+	if err != nil {
+		exit(err)
+	}
+
+	clilogger.Infof("Importing blockchain from '%s'\n", fn)
+	count, err := ethereum.ChainManager().ImportChain(fh)
+	fh.Close()
+	if err == nil {
+		clilogger.Infof("Imported %d blocks\n", count)
+	}
+	exit(err)
+}
+
 func NewKeyManager(KeyStore string, Datadir string, db ethutil.Database) *crypto.KeyManager {
 	var keyManager *crypto.KeyManager
 	switch {
@@ -1,8 +1,12 @@
 package utils
 
 import (
+	"sync"
+
 	"github.com/georzaza/go-ethereum-v0.7.10_official"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ui"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/websocket"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/xeth"
 )
@@ -12,18 +16,76 @@ func args(v ...interface{}) []interface{} {
 }
 
 type WebSocketServer struct {
-	ethereum        *eth.Ethereum
+	ethereum *eth.Ethereum
+
+	// Ids of the filters installed by each client
+	filterMu        sync.Mutex
 	filterCallbacks map[int][]int
 }
 
 func NewWebSocketServer(eth *eth.Ethereum) *WebSocketServer {
-	return &WebSocketServer{eth, make(map[int][]int)}
+	return &WebSocketServer{ethereum: eth, filterCallbacks: make(map[int][]int)}
+}
+
+// Installs the filter described by object for client c. Matching messages and
+// logs are written to the client under the given seed. Returns the filter id.
+func (self *WebSocketServer) installFilter(c *websocket.Client, object map[string]interface{}, seed int) int {
+	filter := ui.NewFilterFromMap(object, self.ethereum)
+	// Messages and logs are written with the same seed, so only the kind the
+	// options ask for is delivered
+	if object["from"] != nil || object["to"] != nil || object["altered"] != nil {
+		filter.MessageCallback = func(messages state.Messages) {
+			c.Write(xeth.ToJSMessages(messages).Interface(), seed)
+		}
+	}
+	if object["address"] != nil || object["topics"] != nil {
+		filter.LogsCallback = func(logs state.Logs) {
+			c.Write(xeth.ToJSLogs(logs).Interface(), seed)
+		}
+	}
+	id := self.ethereum.InstallFilter(filter)
+
+	self.filterMu.Lock()
+	self.filterCallbacks[c.Id()] = append(self.filterCallbacks[c.Id()], id)
+	self.filterMu.Unlock()
+
+	return id
+}
+
+// Uninstalls the filter with the given id if it was installed by client c.
+func (self *WebSocketServer) uninstallFilter(c *websocket.Client, id int) bool {
+	self.filterMu.Lock()
+	defer self.filterMu.Unlock()
+
+	ids := self.filterCallbacks[c.Id()]
+	for i, fid := range ids {
+		if fid == id {
+			self.ethereum.UninstallFilter(id)
+			self.filterCallbacks[c.Id()] = append(ids[:i], ids[i+1:]...)
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// Uninstalls all filters installed by client c, which disconnected.
+func (self *WebSocketServer) uninstallFilters(c *websocket.Client) {
+	self.filterMu.Lock()
+	defer self.filterMu.Unlock()
+
+	for _, id := range self.filterCallbacks[c.Id()] {
+		self.ethereum.UninstallFilter(id)
+	}
+	delete(self.filterCallbacks, c.Id())
 }
 
 func (self *WebSocketServer) Serv() {
 	pipe := xeth.NewJSXEth(self.ethereum)
 
 	wsServ := websocket.NewServer("/eth", ":40404")
+	wsServ.CloseFunc(self.uninstallFilters)
 	wsServ.MessageFunc(func(c *websocket.Client, msg *websocket.Message) {
 		switch msg.Call {
 		case "compile":
@@ -94,7 +156,25 @@ func (self *WebSocketServer) Serv() {
 
 			c.Write(pipe.SecretToAddress(args.Get(0).Str()), msg.Seed)
 
+		case "getTxPoolStatus":
+			c.Write(pipe.TxPoolStatus(), msg.Seed)
+
 		case "newFilter":
+			if mp, ok := msg.Args[0].(map[string]interface{}); ok {
+				c.Write(self.installFilter(c, mp, msg.Seed), msg.Seed)
+			}
+
+		case "uninstallFilter":
+			args := msg.Arguments()
+
+			c.Write(self.uninstallFilter(c, int(args.Get(0).Uint())), msg.Seed)
+
+		case "getLogs":
+			if mp, ok := msg.Args[0].(map[string]interface{}); ok {
+				filter := ui.NewFilterFromMap(mp, self.ethereum)
+				c.Write(xeth.ToJSLogs(filter.FindLogs()).Interface(), msg.Seed)
+			}
+
 		case "newFilterString":
 		case "messages":
 			// TODO
@@ -0,0 +1,127 @@
+package core
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+// Prefix of the keys under which bad blocks are stored
+var badBlockPrefix = []byte("BadBlock")
+
+// Key of the bad block entry of the block with the given hash
+func badBlockKey(hash []byte) []byte {
+	return append(ethutil.CopyBytes(badBlockPrefix), hash...)
+}
+
+// A block which failed validation (see isBadBlockErr). Bad blocks are remembered (across restarts) so they, and any block
+// descending from them, are rejected without being processed again.
+//
+// Hash: The hash of the block.
+//
+// Number: The number of the block.
+//
+// ParentHash: The hash of the block's parent.
+//
+// Reason: Why the block was rejected.
+type BadBlock struct {
+	Hash       []byte
+	Number     *big.Int
+	ParentHash []byte
+	Reason     string
+}
+
+// Returns the rlp-encoding of the bad block, the hash isn't included as it is part of the key.
+func (self *BadBlock) RlpEncode() []byte {
+	return ethutil.Encode([]interface{}{self.Number, self.ParentHash, self.Reason})
+}
+
+// Sets the fields of the bad block, except the hash, from the rlp-encoding created by BadBlock.RlpEncode.
+func (self *BadBlock) RlpDecode(data []byte) {
+	decoder := ethutil.NewValueFromBytes(data)
+
+	self.Number = decoder.Get(0).BigInt()
+	self.ParentHash = decoder.Get(1).Bytes()
+	self.Reason = decoder.Get(2).Str()
+}
+
+// Returns whether a block which failed processing with the given error is bad for good. The hash of a block
+// covers its header only, so a block whose transactions or uncles don't match its header may have been
+// delivered with a tampered body, and the same block with its real body may still be valid. Once the body
+// matched the header (see BlockManager.ValidateBlock), an invalid header or an invalid resulting state
+// (bloom, receipt root or state root) makes the block bad. Blocks from the future may become valid.
+func isBadBlockErr(err error) bool {
+	if !IsValidationErr(err) {
+		return false
+	}
+
+	switch err.(*ValidationErr).Reason {
+	case FutureBlock, InvalidUncle, InvalidTxSha, InvalidUncleSha:
+		return false
+	}
+
+	return true
+}
+
+// Inner function that stores the given block as bad, for the given reason.
+func (self *ChainManager) writeBadBlock(block *types.Block, reason string) {
+	bad := &BadBlock{Hash: block.Hash(), Number: block.Number, ParentHash: block.PrevHash, Reason: reason}
+	if err := ethutil.Config.Db.Put(badBlockKey(bad.Hash), bad.RlpEncode()); err != nil {
+		chainlogger.Errorln("Unable to write bad block:", err)
+	}
+
+	chainlogger.Infof("Marked block #%v (%x) as bad: %s\n", block.Number, bad.Hash[:4], reason)
+}
+
+// Inner function that returns a BadBlockErr if the block is bad or is a child of a bad block, nil
+// otherwise. Children aren't stored as bad, they are unchecked and anyone can make up any number of
+// them. Their own descendants are rejected anyway, as their parent never makes it into the chain.
+func (self *ChainManager) checkBadBlock(block *types.Block) error {
+	if bad := self.GetBadBlock(block.Hash()); bad != nil {
+		return BadBlockError(block.Number, block.Hash(), bad.Reason)
+	}
+
+	if bad := self.GetBadBlock(block.PrevHash); bad != nil {
+		reason := fmt.Sprintf("descends from bad block #%v (%x)", bad.Number, bad.Hash[:4])
+
+		return BadBlockError(block.Number, block.Hash(), reason)
+	}
+
+	return nil
+}
+
+// Returns the bad block with the given hash, nil if the block isn't known to be bad.
+func (self *ChainManager) GetBadBlock(hash []byte) *BadBlock {
+	data, _ := ethutil.Config.Db.Get(badBlockKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+
+	bad := &BadBlock{Hash: hash}
+	bad.RlpDecode(data)
+
+	return bad
+}
+
+// Returns whether the block with the given hash is known to be bad.
+func (self *ChainManager) IsBadBlock(hash []byte) bool {
+	return self.GetBadBlock(hash) != nil
+}
+
+// Returns all bad blocks, ordered by hash.
+func (self *ChainManager) BadBlocks() []*BadBlock {
+	it := ethutil.Config.Db.NewIterator(ethutil.PrefixRange(badBlockPrefix))
+	defer it.Release()
+
+	var blocks []*BadBlock
+	for it.Next() {
+		bad := &BadBlock{Hash: ethutil.CopyBytes(it.Key()[len(badBlockPrefix):])}
+		bad.RlpDecode(it.Value())
+
+		blocks = append(blocks, bad)
+	}
+
+	return blocks
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestChainBadBlocks(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:10]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Blocks from the future may become valid, they aren't bad
+	future := copyBlock(chain[10])
+	future.Time = time.Now().Unix() + 3600
+	if err := chainMan.InsertChain(types.Blocks{future}); !IsValidationErr(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if chainMan.IsBadBlock(future.Hash()) {
+		t.Error("future block marked as bad")
+	}
+
+	bad := copyBlock(chain[10])
+	bad.Extra = strings.Repeat("x", maxExtraDataSize+1)
+	child := copyBlock(chain[11])
+	child.PrevHash = bad.Hash()
+
+	if err := chainMan.InsertChain(types.Blocks{bad, child}); !IsValidationErr(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if !chainMan.IsBadBlock(bad.Hash()) {
+		t.Fatal("block not marked as bad")
+	}
+
+	// Bad blocks and their descendants are rejected without processing
+	if err := chainMan.InsertChain(types.Blocks{bad}); !IsBadBlockErr(err) {
+		t.Errorf("expected bad block error, got %v", err)
+	}
+	if err := chainMan.InsertChain(types.Blocks{child}); !IsBadBlockErr(err) {
+		t.Errorf("expected bad block error for descendant, got %v", err)
+	}
+
+	// Bad blocks are persisted, their descendants aren't
+	chainMan = newChainManagerWithDb(db)
+	badBlocks := chainMan.BadBlocks()
+	if len(badBlocks) != 1 {
+		t.Fatalf("expected 1 bad block, got %d", len(badBlocks))
+	}
+	if b := badBlocks[0]; !bytes.Equal(b.Hash, bad.Hash()) || len(b.Reason) == 0 {
+		t.Errorf("unexpected bad block #%v (%x): %q", b.Number, b.Hash, b.Reason)
+	}
+
+	// The good chain is still accepted
+	if err := chainMan.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChainBadBlockBody(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:10]); err != nil {
+		t.Fatal(err)
+	}
+
+	// The header is left as is, so the tampered block has the hash of the real one
+	tampered := copyBlock(chain[10])
+	txSha := tampered.TxSha
+	tampered.SetTransactions(types.Transactions{types.NewTransactionMessage(make([]byte, 20), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)})
+	tampered.TxSha = txSha
+	if !bytes.Equal(tampered.Hash(), chain[10].Hash()) {
+		t.Fatal("tampered block has a different hash")
+	}
+
+	if err := chainMan.InsertChain(types.Blocks{tampered}); err == nil {
+		t.Fatal("expected error for block with tampered transactions")
+	}
+	if chainMan.IsBadBlock(tampered.Hash()) {
+		t.Fatal("block with tampered transactions marked as bad")
+	}
+
+	// The block is accepted with its real body
+	if err := chainMan.InsertChain(chain[10:]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chainMan.CurrentBlock().Hash(), chain[len(chain)-1].Hash()) {
+		t.Errorf("head is #%v, expected #%v", chainMan.CurrentBlock().Number, chain[len(chain)-1].Number)
+	}
+}
+
+func TestChainBadBlockState(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:10]); err != nil {
+		t.Fatal(err)
+	}
+
+	// Rewarding another miner yields another state than the header commits to
+	bad := copyBlock(chain[10])
+	bad.Coinbase = make([]byte, 20)
+	if err := chainMan.InsertChain(types.Blocks{bad}); !IsValidationErr(err) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if !chainMan.IsBadBlock(bad.Hash()) {
+		t.Fatal("block with an invalid state not marked as bad")
+	}
+
+	if err := chainMan.InsertChain(chain[10:]); err != nil {
+		t.Fatal(err)
+	}
+}
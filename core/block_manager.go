@@ -14,7 +14,6 @@ import (
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/event"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
-	"github.com/georzaza/go-ethereum-v0.7.10_official/pow"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/pow/ezp"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/wire"
@@ -100,7 +99,7 @@ type EthManager interface {
 //
 // mem: non-persistent key/value memory storage
 //
-// Pow: Proof of work used for validating
+// Engine: The consensus rules used for validating and finalizing blocks. See the type 'Engine' of the 'core' package.
 //
 // txpool: The transaction pool. See type 'TxPool' of the 'core' package.
 //
@@ -111,26 +110,33 @@ type EthManager interface {
 // events: Provides a way to subscribe to events.
 //
 // eventMux: event mutex, used to dispatch events to subscribers.
+//
+// verified: The hashes of the blocks whose header was verified ahead of processing. See BlockManager.VerifyChain.
 type BlockManager struct {
 	mutex              sync.Mutex
 	bc                 *ChainManager
 	mem                map[string]*big.Int
-	Pow                pow.PoW
+	Engine             Engine
 	txpool             *TxPool
 	lastAttemptedBlock *types.Block
 	events             event.Subscription
 	eventMux           *event.TypeMux
+
+	verifiedMu sync.Mutex
+	verified   map[string]bool
 }
 
-// Creates a new BlockManager object by initializing these fields of a BlockManager object type: mem, Pow, bc, eventMux, txpool.
-// The Pow object will have it's 'turbo' field set to true when created. See the type 'EasyPow' of the 'ezp' package for more. (file pow/ezp/pow.go)
+// Creates a new BlockManager object by initializing these fields of a BlockManager object type: mem, Engine, bc, eventMux, txpool.
+// The Engine will be a PowEngine using an EasyPow that has it's 'turbo' field set to true. See the type 'EasyPow' of the 'ezp'
+// package for more. (file pow/ezp/pow.go)
 func NewBlockManager(txpool *TxPool, chainManager *ChainManager, eventMux *event.TypeMux) *BlockManager {
 	sm := &BlockManager{
 		mem:      make(map[string]*big.Int),
-		Pow:      ezp.New(),
+		Engine:   NewPowEngine(ezp.New()),
 		bc:       chainManager,
 		eventMux: eventMux,
 		txpool:   txpool,
+		verified: make(map[string]bool),
 	}
 	return sm
 }
@@ -266,7 +272,9 @@ done:
 //
 // Before calling the ProcessWithParent function, this function takes care of locking the BlockManager with a mutex and
 // only after the ProcessWithParent function has returned the BlockManager is being unlocked.
-func (sm *BlockManager) Process(block *types.Block) (td *big.Int, msgs state.Messages, err error) {
+//
+// The state of the processed block is added to the 'batch' param. Nothing is persisted until the caller writes the batch.
+func (sm *BlockManager) Process(block *types.Block, batch ethutil.Batch) (td *big.Int, msgs state.Messages, err error) {
 	sm.mutex.Lock()
 	defer sm.mutex.Unlock()
 	if sm.bc.HasBlock(block.Hash()) {
@@ -276,7 +284,7 @@ func (sm *BlockManager) Process(block *types.Block) (td *big.Int, msgs state.Mes
 		return nil, nil, ParentError(block.PrevHash)
 	}
 	parent := sm.bc.GetBlock(block.PrevHash)
-	return sm.ProcessWithParent(block, parent)
+	return sm.ProcessWithParent(block, parent, batch)
 }
 
 // The main process function of a block. Gets called by the function Process.
@@ -295,14 +303,15 @@ func (sm *BlockManager) Process(block *types.Block) (td *big.Int, msgs state.Mes
 // 4. Creates the bloom field of the receipts returned from step 2. If for some reason the bloom field is different
 // from the bloom field of the provided block, it returns.
 //
-// 5. Validates the transactions and the receipts root hashes. If errors, returns.
+// 5. Validates the receipts root hash. If errors, returns.
 //
-// 6. Calls AccumelateRewards to calculate the miner rewards. If errors, returns.
+// 6. Calls AccumelateRewards to calculate the miner rewards (see Engine). If errors, returns.
 //
 // 7. Sets the state to 0 and makes a call to CalculateTD in order to calculate the total difficulty of the block. If errors, returns.
-// If not, the last step is to remove the block's transactions from the BlockManager's txpool, sync the state db, cancel the queued
-// state reset, send a message to the chainlogger channel and finally return the tuple (td, messages, nil).
-func (sm *BlockManager) ProcessWithParent(block, parent *types.Block) (td *big.Int, messages state.Messages, err error) {
+// If not, the last step is to remove the block's transactions from the BlockManager's txpool, sync the state db to the 'batch' param,
+// queue the block's receipts and messages on the 'batch' param (see ChainManager.GetReceipts and ChainManager.GetMessages),
+// cancel the queued state reset, send a message to the chainlogger channel and finally return the tuple (td, messages, nil).
+func (sm *BlockManager) ProcessWithParent(block, parent *types.Block, batch ethutil.Batch) (td *big.Int, messages state.Messages, err error) {
 	sm.lastAttemptedBlock = block
 	state := parent.State().Copy()
 	defer state.Reset()
@@ -317,18 +326,12 @@ func (sm *BlockManager) ProcessWithParent(block, parent *types.Block) (td *big.I
 	}
 	rbloom := types.CreateBloom(receipts)
 	if bytes.Compare(rbloom, block.LogsBloom) != 0 {
-		err = fmt.Errorf("unable to replicate block's bloom=%x", rbloom)
-		return
-	}
-	txSha := types.DeriveSha(block.Transactions())
-	if bytes.Compare(txSha, block.TxSha) != 0 {
-		err = fmt.Errorf("validating transaction root. received=%x got=%x", block.TxSha, txSha)
+		err = ValidationError(InvalidBloom, "unable to replicate block's bloom=%x", rbloom)
 		return
 	}
 	receiptSha := types.DeriveSha(receipts)
 	if bytes.Compare(receiptSha, block.ReceiptSha) != 0 {
-		fmt.Printf("%x\n", ethutil.Encode(receipts))
-		err = fmt.Errorf("validating receipt root. received=%x got=%x", block.ReceiptSha, receiptSha)
+		err = ValidationError(InvalidReceiptSha, "validating receipt root. received=%x got=%x", block.ReceiptSha, receiptSha)
 		return
 	}
 	if err = sm.AccumelateRewards(state, block, parent); err != nil {
@@ -336,14 +339,19 @@ func (sm *BlockManager) ProcessWithParent(block, parent *types.Block) (td *big.I
 	}
 	state.Update(ethutil.Big0)
 	if !block.State().Cmp(state) {
-		err = fmt.Errorf("invalid merkle root. received=%x got=%x", block.Root(), state.Root())
+		err = ValidationError(InvalidStateRoot, "invalid merkle root. received=%x got=%x", block.Root(), state.Root())
 		return
 	}
 
 	if td, ok := sm.CalculateTD(block); ok {
-		state.Sync()
+		if err = state.SyncTo(batch); err != nil {
+			return nil, nil, err
+		}
 		messages := state.Manifest().Messages
 		state.Manifest().Reset()
+		if err = writeReceipts(batch, block, receipts, messages); err != nil {
+			return nil, nil, err
+		}
 		chainlogger.Infof("Processed block #%d (%x...)\n", block.Number, block.Hash()[0:4])
 		sm.txpool.RemoveSet(block.Transactions())
 		return td, messages, nil
@@ -356,116 +364,142 @@ func (sm *BlockManager) ProcessWithParent(block, parent *types.Block) (td *big.I
 //
 // TD(genesis_block)=0 and TD(block)=TD(block.parent) + sum(u.difficulty for u in block.uncles) + block.difficulty
 //
-// Returns: If the calculated total difficulty is greater than the previous the tuple (total_difficulty, true) is returned.
-// Otherwise, the tuple (nil, false) is returned.
+// Returns: the tuple (total_difficulty, true) or, if the block's parent is unknown, the tuple (nil, false).
 func (sm *BlockManager) CalculateTD(block *types.Block) (*big.Int, bool) {
-	uncleDiff := new(big.Int)
-	for _, uncle := range block.Uncles {
-		uncleDiff = uncleDiff.Add(uncleDiff, uncle.Difficulty)
+	td, err := sm.bc.CalcTotalDiff(block)
+	if err != nil {
+		return nil, false
 	}
 
-	td := new(big.Int)
-	td = td.Add(sm.bc.Td(), uncleDiff)
-	td = td.Add(td, block.Difficulty)
+	return td, true
+}
 
-	if td.Cmp(sm.bc.Td()) > 0 {
-		return td, true
-	}
+// Largest extra data, in bytes, a block header may carry
+const maxExtraDataSize = 1024
 
-	return nil, false
-}
+// How far, in seconds, the timestamp of a block may lie ahead of the local clock
+const allowedFutureBlockTime = 15
 
 // Validates the current block. Returns an error if the block was invalid,
 // an uncle or anything that isn't on the current block chain.
 // Validation validates easy over difficult (dagger takes longer time = difficult)
+//
+// The header of the block and the headers of its uncles are checked by ValidateHeader, each against its own
+// parent. The header of the block isn't checked again if it was verified ahead of processing (see VerifyChain).
+// Whether the uncles may be included by the block is checked when the block is finalized, see
+// AccumelateRewards.
+//
+// The transactions and uncles of the block are checked against the roots in its header before anything
+// is processed, so a failure after this point is caused by the block itself and not by a tampered body.
 func (sm *BlockManager) ValidateBlock(block, parent *types.Block) error {
-	expd := CalcDifficulty(block, parent)
-	if expd.Cmp(block.Difficulty) < 0 {
-		return fmt.Errorf("Difficulty check failed for block %v, %v", block.Difficulty, expd)
+	if !sm.takeVerified(block.Hash()) {
+		if err := sm.ValidateHeader(block, parent); err != nil {
+			return err
+		}
 	}
 
-	diff := block.Time - parent.Time
-	if diff < 0 {
-		return ValidationError("Block timestamp less then prev block %v (%v - %v)", diff, block.Time, sm.bc.CurrentBlock().Time)
+	if txSha := types.DeriveSha(block.Transactions()); !bytes.Equal(txSha, block.TxSha) {
+		return ValidationError(InvalidTxSha, "Transaction root %x doesn't match header %x", txSha, block.TxSha)
 	}
 
-	// Verify the nonce of the block. Return an error if it's not valid
-	if !sm.Pow.Verify(block /*block.HashNoNonce(), block.Difficulty, block.Nonce*/) {
-		return ValidationError("Block's nonce is invalid (= %v)", ethutil.Bytes2Hex(block.Nonce))
+	if uncleSha := block.CalcUncleSha(); !bytes.Equal(uncleSha, block.UncleSha) {
+		return ValidationError(InvalidUncleSha, "Uncle root %x doesn't match header %x", uncleSha, block.UncleSha)
+	}
+
+	for _, uncle := range block.Uncles {
+		uncleParent := sm.bc.GetBlock(uncle.PrevHash)
+		if uncleParent == nil {
+			return UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.PrevHash[0:4]))
+		}
+
+		if err := sm.ValidateHeader(uncle, uncleParent); err != nil {
+			return ValidationError(InvalidUncle, "Uncle %x invalid: %v", uncle.Hash()[0:4], err)
+		}
 	}
 
 	return nil
 }
 
-// Calculates the reward of the miner. Returns an error if an error has occured during the
-// validation process. If no errors have occured, nil is returned.
-//
-// More specifically an error is returned:
-// 1. if the parent of any of the uncles of the 'block' is nil, or
+// Validates the header of a block, or of an uncle, against the header of its parent. Returns a
+// ValidationErr naming the failed check if the header is invalid, nil otherwise.
 //
-// 2. if the (block) number of the parent of any of the uncles of the 'block' and the 'block' itself have a difference greater than 6, or
+// The header is invalid if:
 //
-// 3. if the hash of any of the uncles of the param 'block' matches any of the uncles of the param 'parent'.
+// 1. its number doesn't follow the parent's number, or
 //
-// 4. if the nonce of any of the uncles of the param 'block' is included in the nonce of the 'block'
+// 2. its timestamp is before the parent's or more than allowedFutureBlockTime seconds ahead of the local clock, or
 //
-// The reward to be appointed to the miner will be:
+// 3. its gas limit deviates from Block.CalcGasLimit(parent) by more than 1/1024th of the parent's gas limit, or
+// is below the minimum gas limit, or
 //
-// If the 'block' has 1 uncle: r1 = BlockReward + BlockReward/32,
+// 4. its gas used exceeds its gas limit, or
 //
-// If the 'block' has 2 uncles: r2 = r1 + r1/32, etc., where BlockReward = 1.5 Ether,( defined in the core package, file fees.go)
+// 5. its extra data is longer than maxExtraDataSize, or
 //
-// Finally, a message is added to the state manifest regarding the value to be transferred to the miner address.
-// This value will be the sum of the above calculated reward and the block.Reward.
-func (sm *BlockManager) AccumelateRewards(statedb *state.StateDB, block, parent *types.Block) error {
-	reward := new(big.Int).Set(BlockReward)
-
-	knownUncles := ethutil.Set(parent.Uncles)
-	nonces := ethutil.NewSet(block.Nonce)
-	for _, uncle := range block.Uncles {
-		if nonces.Include(uncle.Nonce) {
-			// Error not unique
-			return UncleError("Uncle not unique")
-		}
+// 6. the Engine's VerifyHeader fails, which checks the difficulty and the seal of the header.
+func (sm *BlockManager) ValidateHeader(block, parent *types.Block) error {
+	if expn := new(big.Int).Add(parent.Number, ethutil.Big1); block.Number.Cmp(expn) != 0 {
+		return ValidationError(InvalidNumber, "Block number %v doesn't follow parent's number %v", block.Number, parent.Number)
+	}
 
-		uncleParent := sm.bc.GetBlock(uncle.PrevHash)
-		if uncleParent == nil {
-			return UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.PrevHash[0:4]))
-		}
+	diff := block.Time - parent.Time
+	if diff < 0 {
+		return ValidationError(InvalidTimestamp, "Block timestamp less then prev block %v (%v - %v)", diff, block.Time, parent.Time)
+	}
+	if now := time.Now().Unix(); block.Time > now+allowedFutureBlockTime {
+		return ValidationError(FutureBlock, "Block timestamp %v is in the future (now %v)", block.Time, now)
+	}
 
-		if uncleParent.Number.Cmp(new(big.Int).Sub(parent.Number, big.NewInt(6))) < 0 {
-			return UncleError("Uncle too old")
-		}
+	expl := block.CalcGasLimit(parent)
+	bound := new(big.Int).Div(parent.GasLimit, big.NewInt(1024))
+	if dev := new(big.Int).Sub(block.GasLimit, expl); dev.Abs(dev).Cmp(bound) > 0 || block.GasLimit.Cmp(types.MinGasLimit) < 0 {
+		return ValidationError(InvalidGasLimit, "Block gas limit %v out of bounds (expected %v +/- %v)", block.GasLimit, expl, bound)
+	}
 
-		if knownUncles.Include(uncle.Hash()) {
-			return UncleError("Uncle in chain")
-		}
+	if block.GasUsed.Cmp(block.GasLimit) > 0 {
+		return ValidationError(InvalidGasUsed, "Block gas used %v exceeds gas limit %v", block.GasUsed, block.GasLimit)
+	}
 
-		nonces.Insert(uncle.Nonce)
+	if len(block.Extra) > maxExtraDataSize {
+		return ValidationError(InvalidExtraData, "Block extra data too long (%d > %d bytes)", len(block.Extra), maxExtraDataSize)
+	}
 
-		r := new(big.Int)
-		r.Mul(BlockReward, big.NewInt(15)).Div(r, big.NewInt(16))
+	return sm.Engine.VerifyHeader(block, parent)
+}
 
-		uncleAccount := statedb.GetAccount(uncle.Coinbase)
-		uncleAccount.AddAmount(r)
+// Credits the rewards of the block on statedb by calling Finalize on the BlockManager's Engine. For the
+// default engines see AccumulateRewards.
+func (sm *BlockManager) AccumelateRewards(statedb *state.StateDB, block, parent *types.Block) error {
+	return sm.Engine.Finalize(sm.bc, statedb, block, parent)
+}
 
-		reward.Add(reward, new(big.Int).Div(BlockReward, big.NewInt(32)))
+// Returns the logs created by the transactions of the block. Like GetMessages the block is processed again on
+// top of its parent's state, which is only needed for blocks whose receipts weren't stored (see
+// ChainManager.GetReceipts). Returns a ParentError if the parent of the block is unknown.
+func (sm *BlockManager) GetLogs(block *types.Block) (logs state.Logs, err error) {
+	if !sm.bc.HasBlock(block.PrevHash) {
+		return nil, ParentError(block.PrevHash)
 	}
 
-	// Get the account associated with the coinbase
-	account := statedb.GetAccount(block.Coinbase)
-	// Reward amount of ether to the coinbase address
-	account.AddAmount(reward)
+	sm.lastAttemptedBlock = block
+
+	var (
+		parent = sm.bc.GetBlock(block.PrevHash)
+		state  = parent.State().Copy()
+	)
 
-	statedb.Manifest().AddMessage(&state.Message{
-		To:     block.Coinbase,
-		Input:  nil,
-		Origin: nil,
-		Block:  block.Hash(), Timestamp: block.Time, Coinbase: block.Coinbase, Number: block.Number,
-		Value: new(big.Int).Add(reward, block.Reward),
-	})
+	defer state.Reset()
 
-	return nil
+	receipts, err := sm.TransitionState(state, parent, block)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, receipt := range receipts {
+		logs = append(logs, receipt.Logs()...)
+	}
+
+	return logs, nil
 }
 
 // Returns either the tuple (state.Manifest().Messages, nil) or (nil, error)
@@ -479,6 +513,9 @@ func (sm *BlockManager) AccumelateRewards(statedb *state.StateDB, block, parent
 //
 // To get those messages a simple trick is used: a deferred call on state.Reset() is queued and only then
 // a call of the function TransitionState and following that a call on AccumelateRewards happen.
+//
+// The messages of every processed block are stored by the ChainManager, see ChainManager.GetMessages. This
+// function is only needed for blocks which were processed before that.
 func (sm *BlockManager) GetMessages(block *types.Block) (messages []*state.Message, err error) {
 	if !sm.bc.HasBlock(block.PrevHash) {
 		return nil, ParentError(block.PrevHash)
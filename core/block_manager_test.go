@@ -0,0 +1,83 @@
+package core
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/pow"
+)
+
+var badNonce = make([]byte, 32)
+
+// testPow accepts the proof of work of every block except those with badNonce,
+// so blocks can be altered without having to mine them again
+type testPow struct {
+	pow.PoW
+}
+
+func (testPow) Verify(block pow.Block) bool {
+	return !bytes.Equal(block.N(), badNonce)
+}
+
+func copyBlock(block *types.Block) *types.Block {
+	return types.NewBlockFromBytes(block.RlpEncode())
+}
+
+func TestValidateBlock(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:10]); err != nil {
+		t.Fatal(err)
+	}
+	blockMan := chainMan.processor.(*BlockManager)
+	blockMan.Engine = NewPowEngine(testPow{})
+
+	parent := chain[9]
+	tests := []struct {
+		name   string
+		mutate func(block *types.Block)
+		valid  bool
+		reason ValidationReason
+	}{
+		{"valid", func(block *types.Block) {}, true, 0},
+		{"number", func(block *types.Block) { block.Number = big.NewInt(12) }, false, InvalidNumber},
+		{"difficulty", func(block *types.Block) { block.Difficulty = new(big.Int).Add(block.Difficulty, ethutil.Big1) }, false, InvalidDifficulty},
+		{"timestamp", func(block *types.Block) { block.Time = parent.Time - 1 }, false, InvalidTimestamp},
+		{"future", func(block *types.Block) { block.Time = time.Now().Unix() + 3600 }, false, FutureBlock},
+		{"gas limit high", func(block *types.Block) { block.GasLimit = new(big.Int).Mul(parent.GasLimit, big.NewInt(2)) }, false, InvalidGasLimit},
+		{"gas limit low", func(block *types.Block) { block.GasLimit = new(big.Int).Div(parent.GasLimit, big.NewInt(2)) }, false, InvalidGasLimit},
+		{"gas used", func(block *types.Block) { block.GasUsed = new(big.Int).Add(block.GasLimit, ethutil.Big1) }, false, InvalidGasUsed},
+		{"extra data", func(block *types.Block) { block.Extra = strings.Repeat("x", maxExtraDataSize+1) }, false, InvalidExtraData},
+		{"nonce", func(block *types.Block) { block.Nonce = badNonce }, false, InvalidNonce},
+		{"uncle", func(block *types.Block) {
+			uncle := copyBlock(chain[9])
+			uncle.GasUsed = new(big.Int).Add(uncle.GasLimit, ethutil.Big1)
+			block.SetUncles([]*types.Block{uncle})
+		}, false, InvalidUncle},
+	}
+
+	for _, test := range tests {
+		block := copyBlock(chain[10])
+		test.mutate(block)
+
+		err := blockMan.ValidateBlock(block, parent)
+		switch {
+		case test.valid && err != nil:
+			t.Errorf("%s: expected block to be valid, got %v", test.name, err)
+		case !test.valid && !IsValidationErr(err):
+			t.Errorf("%s: expected validation error, got %v", test.name, err)
+		case !test.valid && err.(*ValidationErr).Reason != test.reason:
+			t.Errorf("%s: expected reason %q, got %q (%v)", test.name, test.reason, err.(*ValidationErr).Reason, err)
+		}
+	}
+}
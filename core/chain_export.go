@@ -0,0 +1,91 @@
+package core
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/rlp"
+)
+
+// Number of blocks handed to InsertChain at once while importing
+const importBatchSize = 2500
+
+// Writes the blocks from..to (inclusive) of the canonical chain to w. Blocks
+// are written one after another, each as its own RLP encoded value, so the
+// output can be read back one block at a time (see ImportChain).
+func (self *ChainManager) ExportRange(w io.Writer, from, to uint64) error {
+	self.mu.RLock()
+	defer self.mu.RUnlock()
+
+	if head := self.currentBlock.Number.Uint64(); from > to || to > head {
+		return fmt.Errorf("export range #%d - #%d out of bounds (head #%d)", from, to, head)
+	}
+
+	chainlogger.Infof("exporting blocks #%d - #%d\n", from, to)
+
+	for num := from; num <= to; num++ {
+		// Blocks are stored by their RLP encoding, write it as is
+		var data []byte
+		if hash, _ := ethutil.Config.Db.Get(blockNumKey(num)); len(hash) > 0 {
+			data, _ = ethutil.Config.Db.Get(hash)
+		}
+		if len(data) == 0 {
+			return fmt.Errorf("block #%d missing from database", num)
+		}
+
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reads blocks written by ExportRange from r and inserts them into the chain.
+// Blocks are inserted in batches, known blocks are skipped. Returns the number
+// of blocks read.
+func (self *ChainManager) ImportChain(r io.Reader) (int, error) {
+	stream := rlp.NewStream(r)
+
+	var (
+		count  int
+		blocks = make(types.Blocks, 0, importBatchSize)
+	)
+	insert := func() error {
+		if err := self.InsertChain(blocks); err != nil {
+			return err
+		}
+		count += len(blocks)
+		blocks = blocks[:0]
+
+		chainlogger.Infof("imported %d blocks (head #%v)\n", count, self.CurrentBlock().Number)
+
+		return nil
+	}
+
+	for {
+		data, err := stream.Raw()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return count, fmt.Errorf("block %d of import: %v", count+len(blocks), err)
+		}
+
+		blocks = append(blocks, types.NewBlockFromBytes(data))
+		if len(blocks) == importBatchSize {
+			if err := insert(); err != nil {
+				return count, err
+			}
+		}
+	}
+
+	if len(blocks) > 0 {
+		if err := insert(); err != nil {
+			return count, err
+		}
+	}
+
+	return count, nil
+}
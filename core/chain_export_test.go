@@ -0,0 +1,55 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestChainExportImport(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db1, _ := ethdb.NewMemDatabase()
+	chainMan1 := newChainManagerWithDb(db1)
+	if err := chainMan1.InsertChain(chain[:50]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := chainMan1.ExportRange(new(bytes.Buffer), 10, 50); err == nil {
+		t.Error("expected error exporting beyond the head")
+	}
+
+	var part bytes.Buffer
+	if err := chainMan1.ExportRange(&part, 10, 19); err != nil {
+		t.Fatal(err)
+	}
+	var expected []byte
+	for _, block := range chain[10:20] {
+		expected = append(expected, block.RlpEncode()...)
+	}
+	if !bytes.Equal(part.Bytes(), expected) {
+		t.Error("exported range doesn't match blocks #10 - #19")
+	}
+
+	var full bytes.Buffer
+	if err := chainMan1.ExportRange(&full, 0, 49); err != nil {
+		t.Fatal(err)
+	}
+
+	db2, _ := ethdb.NewMemDatabase()
+	chainMan2 := newChainManagerWithDb(db2)
+	count, err := chainMan2.ImportChain(&full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 50 {
+		t.Errorf("expected 50 blocks to be read, got %d", count)
+	}
+	if !bytes.Equal(chainMan2.CurrentBlock().Hash(), chain[49].Hash()) {
+		t.Errorf("expected head #%v, got #%v", chain[49].Number, chainMan2.CurrentBlock().Number)
+	}
+}
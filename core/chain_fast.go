@@ -0,0 +1,141 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+)
+
+// Implemented by block processors which can validate a header against its
+// parent without processing the block (see BlockManager.ValidateHeader).
+type headerValidator interface {
+	ValidateHeader(block, parent *types.Block) error
+}
+
+// Stores the blocks of the chain without processing them, for fast sync. Every block must follow a stored
+// block; its header is validated against its parent (if the processor supports it, see
+// BlockManager.ValidateHeader) and its transactions and uncles are checked against the header.
+//
+// The blocks are written together with their block info (and total difficulty), but neither become the
+// head nor part of the canonical chain: that happens once the state of one of them was downloaded (see
+// CommitFastHead). Receipts aren't available for blocks inserted this way. Known blocks are skipped.
+func (self *ChainManager) InsertFastChain(chain types.Blocks) error {
+	validator, _ := self.processor.(headerValidator)
+
+	var (
+		batch = ethutil.Config.Db.NewBatch()
+		tds   = make(map[string]*big.Int)
+	)
+	for i, block := range chain {
+		if self.HasBlock(block.Hash()) {
+			continue
+		}
+		if err := self.checkBadBlock(block); err != nil {
+			return err
+		}
+
+		var parent *types.Block
+		if i > 0 && bytes.Equal(chain[i-1].Hash(), block.PrevHash) {
+			parent = chain[i-1]
+		} else if parent = self.GetBlock(block.PrevHash); parent == nil {
+			return ParentError(block.PrevHash)
+		}
+
+		if err := self.validateFast(validator, block, parent); err != nil {
+			chainlogger.Infof("block #%v fast insert failed (%x)\n", block.Number, block.Hash()[:4])
+			chainlogger.Infoln(err)
+			if isBadBlockErr(err) {
+				self.writeBadBlock(block, err.Error())
+			}
+			return err
+		}
+
+		// The parent may still be queued on the batch, in which case its total difficulty isn't stored yet
+		td, ok := tds[string(block.PrevHash)]
+		if !ok {
+			td = parent.BlockInfo().TD
+		}
+		td = new(big.Int).Add(td, block.Difficulty)
+		for _, uncle := range block.Uncles {
+			td.Add(td, uncle.Difficulty)
+		}
+		if err := self.write(batch, block, td); err != nil {
+			return WriteError(block.Number, block.Hash(), err)
+		}
+		tds[string(block.Hash())] = td
+	}
+
+	return batch.Write()
+}
+
+// Inner function that checks a block for InsertFastChain
+func (self *ChainManager) validateFast(validator headerValidator, block, parent *types.Block) error {
+	if validator != nil {
+		if err := validator.ValidateHeader(block, parent); err != nil {
+			return err
+		}
+	}
+
+	if txSha := types.DeriveSha(block.Transactions()); !bytes.Equal(txSha, block.TxSha) {
+		return ValidationError(InvalidTxSha, "Transaction root %x doesn't match header %x", txSha, block.TxSha)
+	}
+
+	if uncleSha := block.CalcUncleSha(); !bytes.Equal(uncleSha, block.UncleSha) {
+		return ValidationError(InvalidUncleSha, "Uncle root %x doesn't match header %x", uncleSha, block.UncleSha)
+	}
+
+	return nil
+}
+
+// Makes the block with the given hash, which was stored by InsertFastChain, the head of the chain once its
+// state is in the database. The canonical number index and the transaction lookup entries are written
+// from the common ancestor with the old head onwards, as if the blocks had been processed.
+//
+// Returns an error if the block is unknown, its state is incomplete or its total difficulty isn't higher
+// than the current one. A ChainHeadEvent is posted for the new head.
+func (self *ChainManager) CommitFastHead(hash []byte) error {
+	block, split, err := self.commitFastHead(hash)
+	if err != nil {
+		return err
+	}
+
+	chainlogger.Infof("Fast synced to #%v (%x)\n", block.Number, block.Hash()[:4])
+
+	if split != nil {
+		self.eventMux.Post(*split)
+	}
+	self.eventMux.Post(ChainHeadEvent{block})
+
+	return nil
+}
+
+// Inner function that makes the block the head for CommitFastHead
+func (self *ChainManager) commitFastHead(hash []byte) (*types.Block, *ChainSplitEvent, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	block := self.GetBlock(hash)
+	if block == nil {
+		return nil, nil, fmt.Errorf("block %x missing from database", hash)
+	}
+	if err := state.MarkNodes(ethutil.Config.Db, block.State().Root(), make(map[string]bool)); err != nil {
+		return nil, nil, fmt.Errorf("state of block #%v incomplete: %v", block.Number, err)
+	}
+
+	td := block.BlockInfo().TD
+	if td.Cmp(self.td) <= 0 {
+		return nil, nil, fmt.Errorf("block #%v total difficulty %v not above head's %v", block.Number, td, self.td)
+	}
+
+	split, err := self.commit(ethutil.Config.Db.NewBatch(), block, td)
+	if err != nil {
+		return nil, nil, err
+	}
+	self.transState = block.State().Copy()
+
+	return block, split, nil
+}
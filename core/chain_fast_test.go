@@ -0,0 +1,81 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+)
+
+func TestChainFastSync(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	// The chain is processed into source, which serves the pivot's state
+	source, _ := ethdb.NewMemDatabase()
+	full := newChainManagerWithDb(source)
+	if err := full.InsertChain(loadChain("chain1", t)); err != nil {
+		t.Fatal(err)
+	}
+
+	chain := loadChain("chain1", t)
+	pivot := chain[len(chain)-3]
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertFastChain(chain[:len(chain)-2]); err != nil {
+		t.Fatal(err)
+	}
+	if num := chainMan.CurrentBlock().Number.Uint64(); num != 0 {
+		t.Errorf("head is #%d after fast insert, expected #0", num)
+	}
+	if err := chainMan.CommitFastHead(pivot.Hash()); err == nil {
+		t.Error("expected error committing pivot without state")
+	}
+
+	// Blocks whose transactions don't match the header are rejected
+	chainMan.processor.(*BlockManager).Engine = NewPowEngine(testPow{})
+	bad := copyBlock(chain[len(chain)-2])
+	bad.TxSha = make([]byte, 32)
+	if err := chainMan.InsertFastChain(types.Blocks{bad}); !IsValidationErr(err) || err.(*ValidationErr).Reason != InvalidTxSha {
+		t.Errorf("expected invalid transaction root error, got %v", err)
+	}
+
+	sync := state.NewStateSync(db, pivot.State().Root())
+	for hashes := sync.Missing(16); len(hashes) > 0; hashes = sync.Missing(16) {
+		var data [][]byte
+		for _, hash := range hashes {
+			entry, _ := source.Get(hash)
+			data = append(data, entry)
+		}
+		if _, err := sync.Process(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sync.Pending() != 0 {
+		t.Fatalf("%d state entries still pending", sync.Pending())
+	}
+
+	if err := chainMan.CommitFastHead(pivot.Hash()); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chainMan.CurrentBlock().Hash(), pivot.Hash()) {
+		t.Errorf("head is #%v, expected pivot #%v", chainMan.CurrentBlock().Number, pivot.Number)
+	}
+	if block := chainMan.GetBlockByNumber(1); block == nil || !bytes.Equal(block.Hash(), chain[1].Hash()) {
+		t.Error("blocks below the pivot missing from the number index")
+	}
+
+	// The blocks above the pivot are processed on top of the downloaded state
+	if err := chainMan.InsertChain(chain[len(chain)-2:]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chainMan.CurrentBlock().Hash(), full.CurrentBlock().Hash()) {
+		t.Errorf("head is #%v, expected #%v", chainMan.CurrentBlock().Number, full.CurrentBlock().Number)
+	}
+	if chainMan.Td().Cmp(full.Td()) != 0 {
+		t.Errorf("td mismatch: got %v, expected %v", chainMan.Td(), full.Td())
+	}
+}
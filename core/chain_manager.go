@@ -1,6 +1,7 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
 	"math/big"
 	"sync"
@@ -129,9 +130,16 @@ func (self *ChainManager) CurrentBlock() *types.Block {
 }
 
 // Creates and returns a new ChainManager object by setting the genesisBlock and the eventMux field of the ChainManager.
+// The default genesis block is used (see DefaultGenesis).
 func NewChainManager(mux *event.TypeMux) *ChainManager {
+	return NewChainManagerWithGenesis(DefaultGenesis(), mux)
+}
+
+// Same as NewChainManager but the chain starts at the given genesis block,
+// e.g. one created from a GenesisSpec.
+func NewChainManagerWithGenesis(genesis *types.Block, mux *event.TypeMux) *ChainManager {
 	bc := &ChainManager{}
-	bc.genesisBlock = types.NewBlockFromBytes(ethutil.Encode(Genesis))
+	bc.genesisBlock = genesis
 	bc.eventMux = mux
 
 	bc.setLastBlock()
@@ -157,13 +165,10 @@ func (self *ChainManager) TransState() *state.StateDB {
 }
 
 // An inner function, used by the ChainManager 'constructor' function that sets the last block of the ChainManager.
-// If the chain has 0 blocks so far, it makes a call to the function AddTestNetFunds(genesisBlock).
+// If the chain has 0 blocks so far, the chain is reset to the genesis block.
 func (bc *ChainManager) setLastBlock() {
 	data, _ := ethutil.Config.Db.Get([]byte("LastBlock"))
 	if len(data) != 0 {
-		// Prep genesis
-		AddTestNetFunds(bc.genesisBlock)
-
 		block := types.NewBlockFromBytes(data)
 		bc.currentBlock = block
 		bc.lastBlockHash = block.Hash()
@@ -211,18 +216,25 @@ func (bc *ChainManager) NewBlock(coinbase []byte) *types.Block {
 	return block
 }
 
-// Resets the chain to the point where the chain will only contain the genesis block. This includes the call on the
-// function AddTestNetFunds.
+// Resets the chain to the point where the chain will only contain the genesis block. The genesis state (and the
+// storage of its accounts) is written along with the block.
 func (bc *ChainManager) Reset() {
 	bc.mu.Lock()
 	defer bc.mu.Unlock()
-	AddTestNetFunds(bc.genesisBlock)
-	bc.genesisBlock.Trie().Sync()
-	bc.write(bc.genesisBlock)
-	bc.insert(bc.genesisBlock)
-	bc.currentBlock = bc.genesisBlock
 
-	bc.setTotalDifficulty(ethutil.Big("0"))
+	// Without a current block the genesis block always becomes the head
+	bc.td = ethutil.Big("0")
+	bc.currentBlock = nil
+
+	batch := ethutil.Config.Db.NewBatch()
+	if err := bc.genesisBlock.State().SyncTo(batch); err != nil {
+		chainlogger.Errorln("Unable to write genesis state:", err)
+	}
+	if _, err := bc.commit(batch, bc.genesisBlock, bc.td); err != nil {
+		chainlogger.Errorln("Unable to write genesis block:", err)
+		bc.currentBlock = bc.genesisBlock
+		bc.lastBlockHash = bc.genesisBlock.Hash()
+	}
 
 	// Set the last know difficulty (might be 0x0 as initial value, Genesis)
 	bc.td = ethutil.BigD(ethutil.Config.Db.LastKnownTD())
@@ -244,20 +256,266 @@ func (self *ChainManager) Export() []byte {
 }
 
 // Inner function, used to insert a block on the chain. What actually gets inserted into the chain is
-// the block's rlp-encoding.
-func (bc *ChainManager) insert(block *types.Block) {
+// the block's rlp-encoding. The write is queued on the given batch.
+func (bc *ChainManager) insert(batch ethutil.Batch, block *types.Block) error {
 	encodedBlock := block.RlpEncode()
-	ethutil.Config.Db.Put([]byte("LastBlock"), encodedBlock)
-	bc.currentBlock = block
-	bc.lastBlockHash = block.Hash()
+	return batch.Put([]byte("LastBlock"), encodedBlock)
 }
 
-// Inner function, used to write a block to the database
-func (bc *ChainManager) write(block *types.Block) {
-	bc.writeBlockInfo(block)
+// Inner function, used to queue the block and its block info on the given batch
+func (bc *ChainManager) write(batch ethutil.Batch, block *types.Block, td *big.Int) error {
+	if err := bc.writeBlockInfo(batch, block, td); err != nil {
+		return err
+	}
 
 	encodedBlock := block.RlpEncode()
-	ethutil.Config.Db.Put(block.Hash(), encodedBlock)
+	return batch.Put(block.Hash(), encodedBlock)
+}
+
+// Inner function that adds the block to the given batch, which already holds the block's state, and writes
+// the batch to the database. If td is higher than the current total difficulty the block also becomes the
+// head of the chain and the canonical number index is rewritten from the common ancestor of the old and
+// the new head onwards. If the block didn't extend the old head a ChainSplitEvent describing the
+// reorganisation is returned.
+//
+// The ChainManager fields are only updated once the batch has been written, so a failed write leaves
+// both the database and the ChainManager untouched.
+func (bc *ChainManager) commit(batch ethutil.Batch, block *types.Block, td *big.Int) (*ChainSplitEvent, error) {
+	if err := bc.write(batch, block, td); err != nil {
+		return nil, err
+	}
+
+	var split *ChainSplitEvent
+
+	head := bc.currentBlock == nil || td.Cmp(bc.td) > 0
+	if head {
+		if bc.currentBlock != nil {
+			oldChain, newChain, ancestor, err := bc.findFork(block)
+			if err != nil {
+				return nil, err
+			}
+			if err := bc.writeCanonical(batch, newChain, bc.currentBlock); err != nil {
+				return nil, err
+			}
+
+			if len(oldChain) > 0 {
+				removed := removedTransactions(oldChain, newChain)
+				if err := deleteTxLookups(batch, removed); err != nil {
+					return nil, err
+				}
+
+				split = &ChainSplitEvent{block, ancestor, oldChain, newChain, removed}
+			}
+		} else if err := bc.writeCanonical(batch, types.Blocks{block}, nil); err != nil {
+			return nil, err
+		}
+
+		if err := bc.setTotalDifficulty(batch, td); err != nil {
+			return nil, err
+		}
+		if err := bc.insert(batch, block); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+
+	if head {
+		bc.td = td
+		bc.currentBlock = block
+		bc.lastBlockHash = block.Hash()
+		bc.lastBlockNumber = block.Number.Uint64()
+	}
+
+	return split, nil
+}
+
+// Inner function that finds the common ancestor of the current head and the given block, which isn't
+// necessarily stored yet. Returns the blocks of the canonical chain above the ancestor (oldChain) and the
+// blocks leading from the ancestor to the given block (newChain), both ordered from the highest block down.
+// oldChain is empty if the block extends the canonical chain.
+func (bc *ChainManager) findFork(block *types.Block) (oldChain, newChain types.Blocks, ancestor *types.Block, err error) {
+	oldBlock, newBlock := bc.currentBlock, block
+
+	parent := func(b *types.Block) (*types.Block, error) {
+		p := bc.GetBlock(b.PrevHash)
+		if p == nil {
+			return nil, fmt.Errorf("unable to find common ancestor, block %x missing", b.PrevHash)
+		}
+
+		return p, nil
+	}
+
+	for newBlock.Number.Cmp(oldBlock.Number) > 0 {
+		newChain = append(newChain, newBlock)
+		if newBlock, err = parent(newBlock); err != nil {
+			return
+		}
+	}
+	for oldBlock.Number.Cmp(newBlock.Number) > 0 {
+		oldChain = append(oldChain, oldBlock)
+		if oldBlock, err = parent(oldBlock); err != nil {
+			return
+		}
+	}
+	for !bytes.Equal(oldBlock.Hash(), newBlock.Hash()) {
+		oldChain = append(oldChain, oldBlock)
+		newChain = append(newChain, newBlock)
+		if oldBlock, err = parent(oldBlock); err != nil {
+			return
+		}
+		if newBlock, err = parent(newBlock); err != nil {
+			return
+		}
+	}
+
+	return oldChain, newChain, oldBlock, nil
+}
+
+// Returns the transactions of the old chain which aren't included in the new chain, oldest first.
+func removedTransactions(oldChain, newChain types.Blocks) types.Transactions {
+	included := make(map[string]bool)
+	for _, block := range newChain {
+		for _, tx := range block.Transactions() {
+			included[string(tx.Hash())] = true
+		}
+	}
+
+	var removed types.Transactions
+	for i := len(oldChain) - 1; i >= 0; i-- {
+		for _, tx := range oldChain[i].Transactions() {
+			if !included[string(tx.Hash())] {
+				removed = append(removed, tx)
+			}
+		}
+	}
+
+	return removed
+}
+
+// Key of the canonical number index entry for the given block number
+func blockNumKey(num uint64) []byte {
+	return append([]byte("BlockNum"), ethutil.NumberToBytes(num, 64)...)
+}
+
+// Inner function that queues the canonical number index entries and the transaction lookup entries of the
+// blocks of newChain on the given batch. Number index entries above the new head which are left over from
+// the old head are removed.
+func (bc *ChainManager) writeCanonical(batch ethutil.Batch, newChain types.Blocks, oldHead *types.Block) error {
+	for _, block := range newChain {
+		if err := batch.Put(blockNumKey(block.Number.Uint64()), block.Hash()); err != nil {
+			return err
+		}
+		if err := writeTxLookups(batch, block); err != nil {
+			return err
+		}
+	}
+
+	if oldHead != nil && len(newChain) > 0 {
+		for num := newChain[0].Number.Uint64() + 1; num <= oldHead.Number.Uint64(); num++ {
+			if err := batch.Delete(blockNumKey(num)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Key of the lookup entry of the transaction with the given hash
+func txLookupKey(hash []byte) []byte {
+	return append([]byte("TxLookup"), hash...)
+}
+
+// Inner function that queues a lookup entry for every transaction of the block on the given batch. An entry
+// holds the hash of the block and the index of the transaction within the block.
+func writeTxLookups(batch ethutil.Batch, block *types.Block) error {
+	for i, tx := range block.Transactions() {
+		if err := batch.Put(txLookupKey(tx.Hash()), ethutil.Encode([]interface{}{block.Hash(), uint64(i)})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Inner function that queues the removal of the lookup entries of the given transactions on the given batch.
+func deleteTxLookups(batch ethutil.Batch, txs types.Transactions) error {
+	for _, tx := range txs {
+		if err := batch.Delete(txLookupKey(tx.Hash())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Returns the hash of the canonical block which includes the transaction with the given hash and the index
+// of the transaction within that block. The hash is nil if the transaction isn't known.
+func (self *ChainManager) txLookup(hash []byte) ([]byte, uint64) {
+	data, _ := ethutil.Config.Db.Get(txLookupKey(hash))
+	if len(data) == 0 {
+		return nil, 0
+	}
+
+	entry := ethutil.NewValueFromBytes(data)
+
+	return entry.Get(0).Bytes(), entry.Get(1).Uint()
+}
+
+// Returns the transaction with the given hash, the block of the canonical chain which includes it and its
+// index within that block. The transaction and the block are nil if no block of the canonical chain
+// includes the transaction.
+func (self *ChainManager) GetTransaction(hash []byte) (*types.Transaction, *types.Block, uint64) {
+	blockHash, index := self.txLookup(hash)
+	if blockHash == nil {
+		return nil, nil, 0
+	}
+
+	block := self.GetBlock(blockHash)
+	if block == nil || index >= uint64(len(block.Transactions())) {
+		return nil, nil, 0
+	}
+
+	return block.Transactions()[index], block, index
+}
+
+// Returns the receipt of the transaction with the given hash along with the block including the transaction
+// and its index within the block. The receipt is nil if the transaction isn't included in the canonical chain
+// or no receipts are stored for its block. Both are looked up through the same lookup entry, so they match
+// even if the chain is reorganised meanwhile.
+func (self *ChainManager) GetReceipt(hash []byte) (*types.Receipt, *types.Block, uint64) {
+	_, receipt, block, index := self.GetTransactionReceipt(hash)
+	if receipt == nil {
+		return nil, nil, 0
+	}
+
+	return receipt, block, index
+}
+
+// Returns the transaction with the given hash, its receipt, the block of the canonical chain which includes
+// it and its index within that block, all through the same lookup entry (see GetReceipt). The transaction
+// and the block are nil if no block of the canonical chain includes the transaction, the receipt is nil as
+// well if no receipts are stored for the block.
+func (self *ChainManager) GetTransactionReceipt(hash []byte) (*types.Transaction, *types.Receipt, *types.Block, uint64) {
+	blockHash, index := self.txLookup(hash)
+	if blockHash == nil {
+		return nil, nil, nil, 0
+	}
+
+	block := self.GetBlock(blockHash)
+	if block == nil || index >= uint64(len(block.Transactions())) {
+		return nil, nil, nil, 0
+	}
+
+	var receipt *types.Receipt
+	if receipts := self.GetReceipts(blockHash); index < uint64(len(receipts)) {
+		receipt = receipts[index]
+	}
+
+	return block.Transactions()[index], receipt, block, index
 }
 
 // Returns the genesis block.
@@ -302,29 +560,20 @@ func (self *ChainManager) GetBlock(hash []byte) *types.Block {
 	return types.NewBlockFromBytes(data)
 }
 
-// Returns the block of the chain that has the given num .
+// Returns the block of the canonical chain that has the given num. The block is looked up in the
+// canonical number index.
 func (self *ChainManager) GetBlockByNumber(num uint64) *types.Block {
-	self.mu.RLock()
-	defer self.mu.RUnlock()
-
-	block := self.currentBlock
-	for ; block != nil; block = self.GetBlock(block.PrevHash) {
-		if block.Number.Uint64() == num {
-			break
-		}
-	}
-
-	if block != nil && block.Number.Uint64() == 0 && num != 0 {
+	hash, _ := ethutil.Config.Db.Get(blockNumKey(num))
+	if len(hash) == 0 {
 		return nil
 	}
 
-	return block
+	return self.GetBlock(hash)
 }
 
-// Sets the total difficulty of the ChainManager object. Also stores that information on the database.
-func (bc *ChainManager) setTotalDifficulty(td *big.Int) {
-	ethutil.Config.Db.Put([]byte("LTD"), td.Bytes())
-	bc.td = td
+// Queues the total difficulty of the ChainManager object on the given batch.
+func (bc *ChainManager) setTotalDifficulty(batch ethutil.Batch, td *big.Int) error {
+	return batch.Put([]byte("LTD"), td.Bytes())
 }
 
 // Calculates the total difficulty of the ChainManager and returns it in a tuple (td, nil). If an error
@@ -360,13 +609,67 @@ func (bc *ChainManager) BlockInfo(block *types.Block) types.BlockInfo {
 	return bi
 }
 
-// Inner function for writing extra non-essential block info to the database.
-func (bc *ChainManager) writeBlockInfo(block *types.Block) {
-	bc.lastBlockNumber++
-	bi := types.BlockInfo{Number: bc.lastBlockNumber, Hash: block.Hash(), Parent: block.PrevHash, TD: bc.td}
+// Inner function for writing extra non-essential block info, including the block's total difficulty, to the
+// given batch.
+func (bc *ChainManager) writeBlockInfo(batch ethutil.Batch, block *types.Block, td *big.Int) error {
+	bi := types.BlockInfo{Number: block.Number.Uint64(), Hash: block.Hash(), Parent: block.PrevHash, TD: td}
 
 	// For now we use the block hash with the words "info" appended as key
-	ethutil.Config.Db.Put(append(block.Hash(), []byte("Info")...), bi.RlpEncode())
+	return batch.Put(append(block.Hash(), []byte("Info")...), bi.RlpEncode())
+}
+
+// Keys of the receipts and the messages stored for the block with the given hash
+func receiptsKey(hash []byte) []byte { return append([]byte("Receipts"), hash...) }
+func messagesKey(hash []byte) []byte { return append([]byte("Messages"), hash...) }
+
+// Inner function that queues the receipts and the messages produced by processing the block on the given
+// batch, so that they can be looked up later without processing the block again.
+func writeReceipts(batch ethutil.Batch, block *types.Block, receipts types.Receipts, messages state.Messages) error {
+	if err := batch.Put(receiptsKey(block.Hash()), receipts.RlpEncode()); err != nil {
+		return err
+	}
+
+	return batch.Put(messagesKey(block.Hash()), messages.RlpEncode())
+}
+
+// Returns the receipts of the transactions of the block with the given hash, as they were stored when the
+// block was inserted. Returns nil if no receipts are stored for the block.
+func (self *ChainManager) GetReceipts(hash []byte) types.Receipts {
+	data, _ := ethutil.Config.Db.Get(receiptsKey(hash))
+	if len(data) == 0 {
+		return nil
+	}
+
+	return types.NewReceiptsFromValue(ethutil.NewValueFromBytes(data))
+}
+
+// Returns the logs of the block with the given hash, in the order they were created. The logs are taken
+// from the stored receipts, see GetReceipts.
+func (self *ChainManager) GetLogs(hash []byte) (logs state.Logs) {
+	for _, receipt := range self.GetReceipts(hash) {
+		logs = append(logs, receipt.Logs()...)
+	}
+
+	return
+}
+
+// Returns the messages (see state.Message) which were created while the block with the given hash was
+// processed, as they were stored when the block was inserted. The bool is false if no messages are stored
+// for the block, which is the case for blocks inserted before messages were stored.
+func (self *ChainManager) GetMessages(hash []byte) (state.Messages, bool) {
+	data, _ := ethutil.Config.Db.Get(messagesKey(hash))
+	if len(data) == 0 {
+		return nil, false
+	}
+
+	var messages state.Messages
+
+	it := ethutil.NewValueFromBytes(data).NewIterator()
+	for it.Next() {
+		messages = append(messages, state.NewMessageFromValue(it.Value()))
+	}
+
+	return messages, true
 }
 
 // Sends a stop message to the chain logger channel if and only if the currentBlock field
@@ -379,51 +682,103 @@ func (bc *ChainManager) Stop() {
 
 // This function iterates over the blocks in the chain param and does the following:
 //
-// 1. It calls the `Process` method of the `BlockProcessor` interface.
+// 1. It calls the `Process` method of the `BlockProcessor` interface. If the processor is a BlockManager the
+// headers and transaction senders of the chain are verified concurrently ahead of processing (see
+// BlockManager.VerifyChain) and a block which fails verification isn't processed at all. A block which
+// fails for good is remembered as bad (see BadBlocks); bad blocks and their descendants are rejected with
+// a BadBlockErr without being processed.
 //
 // 2. writes the block to the database.
 //
 // 4. sets the total difficulty of the block.
 //
-// 5. inserts the block into the chain.
+// 5. inserts the block into the chain. If the block is heavier than the current head it becomes the new
+// head, which reorganises the chain if the block doesn't extend the current head.
+//
+// 6. posts a `NewBlockEvent` to the event mux. If the chain was reorganised a `ChainSplitEvent` follows and
+// if the block became the head a `ChainHeadEvent`.
 //
-// 6. posts a `NewBlockEvent` to the event mux.
+// 7. posts the messages to the event mux, followed by the logs of the block (as state.Logs) if it has any.
 //
-// 7. posts the messages to the event mux.
+// Steps 1 to 5 are collected in a single database batch per block, so a block and its state are either
+// persisted together or not at all. If the batch can't be written the insertion is aborted and a WriteErr
+// naming the failing block is returned.
 //
 // Returns: either nil for success or an error.
 func (self *ChainManager) InsertChain(chain types.Blocks) error {
-	for _, block := range chain {
-		td, messages, err := self.processor.Process(block)
+	// Verify the blocks ahead of processing them, if the processor supports it
+	var verification *ChainVerification
+	if verifier, ok := self.processor.(chainVerifier); ok && verifyWorkers > 0 && len(chain) > 1 {
+		verification = verifier.VerifyChain(chain)
+		defer verification.Stop()
+	}
+
+	for i, block := range chain {
+		var (
+			td       *big.Int
+			messages state.Messages
+			err      error
+		)
+		batch := ethutil.Config.Db.NewBatch()
+		err = self.checkBadBlock(block)
+		if err == nil && verification != nil {
+			if err = verification.Wait(i); err != nil && self.HasBlock(block.Hash()) {
+				err = &KnownBlockError{block.Number, block.Hash()}
+			}
+		}
+		if err == nil {
+			td, messages, err = self.processor.Process(block, batch)
+		}
 		if err != nil {
 			if IsKnownBlockErr(err) {
 				continue
 			}
+			if IsBadBlockErr(err) {
+				chainlogger.Infoln(err)
+				return err
+			}
 
 			chainlogger.Infof("block #%v process failed (%x)\n", block.Number, block.Hash()[:4])
 			chainlogger.Infoln(block)
 			chainlogger.Infoln(err)
+			if isBadBlockErr(err) {
+				self.writeBadBlock(block, err.Error())
+			}
 			return err
 		}
 
+		var (
+			split *ChainSplitEvent
+			head  bool
+		)
 		self.mu.Lock()
 		{
-			self.write(block)
-			if td.Cmp(self.td) > 0 {
-				if block.Number.Cmp(new(big.Int).Add(self.currentBlock.Number, ethutil.Big1)) < 0 {
-					chainlogger.Infof("Split detected. New head #%v (%x), was #%v (%x)\n", block.Number, block.Hash()[:4], self.currentBlock.Number, self.currentBlock.Hash()[:4])
-				}
-
-				self.setTotalDifficulty(td)
-				self.insert(block)
+			if split, err = self.commit(batch, block, td); err == nil && self.currentBlock == block {
+				head = true
 				self.transState = self.currentBlock.State().Copy()
 			}
-
 		}
 		self.mu.Unlock()
 
+		if err != nil {
+			chainlogger.Infof("block #%v write failed (%x)\n", block.Number, block.Hash()[:4])
+			chainlogger.Infoln(err)
+			return WriteError(block.Number, block.Hash(), err)
+		}
+
 		self.eventMux.Post(NewBlockEvent{block})
+		if split != nil {
+			old := split.OldChain[0]
+			chainlogger.Infof("Split detected. New head #%v (%x), was #%v (%x). Common ancestor #%v (%x)\n", block.Number, block.Hash()[:4], old.Number, old.Hash()[:4], split.Ancestor.Number, split.Ancestor.Hash()[:4])
+			self.eventMux.Post(*split)
+		}
+		if head {
+			self.eventMux.Post(ChainHeadEvent{block})
+		}
 		self.eventMux.Post(messages)
+		if logs := self.GetLogs(block.Hash()); len(logs) > 0 {
+			self.eventMux.Post(logs)
+		}
 	}
 
 	return nil
@@ -1,7 +1,10 @@
 package core
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"math/big"
 	"path"
 	"runtime"
 	"testing"
@@ -26,7 +29,7 @@ func init() {
 	ethutil.Config.Db = db
 }
 
-func loadChain(fn string, t *testing.T) types.Blocks {
+func loadChain(fn string, t testing.TB) types.Blocks {
 	c1, err := ethutil.ReadAllFile(path.Join("..", "_data", fn))
 	if err != nil {
 		fmt.Println(err)
@@ -71,3 +74,312 @@ func TestChainInsertions(t *testing.T) {
 	}
 	fmt.Println(chainMan.CurrentBlock())
 }
+
+func newChainManagerWithDb(db ethutil.Database) *ChainManager {
+	ethutil.Config.Db = db
+
+	var eventMux event.TypeMux
+	chainMan := NewChainManager(&eventMux)
+	txPool := NewTxPool(chainMan, nil, &eventMux)
+	blockMan := NewBlockManager(txPool, chainMan, &eventMux)
+	chainMan.SetProcessor(blockMan)
+
+	return chainMan
+}
+
+var errFaultyDb = errors.New("faulty database: write refused")
+
+// faultyDb is an in-memory database which refuses every write once fail is set
+type faultyDb struct {
+	*ethdb.MemDatabase
+	fail bool
+}
+
+func (db *faultyDb) Put(key []byte, value []byte) error {
+	if db.fail {
+		return errFaultyDb
+	}
+
+	return db.MemDatabase.Put(key, value)
+}
+
+func (db *faultyDb) NewBatch() ethutil.Batch {
+	return &faultyBatch{db, db.MemDatabase.NewBatch()}
+}
+
+type faultyBatch struct {
+	db *faultyDb
+	ethutil.Batch
+}
+
+func (b *faultyBatch) Write() error {
+	if b.db.fail {
+		return errFaultyDb
+	}
+
+	return b.Batch.Write()
+}
+
+func TestChainInsertWriteFailure(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	mem, _ := ethdb.NewMemDatabase()
+	db := &faultyDb{MemDatabase: mem}
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+	chainMan := newChainManagerWithDb(db)
+
+	if err := chainMan.InsertChain(chain[:10]); err != nil {
+		t.Fatal(err)
+	}
+	head, td := chainMan.CurrentBlock(), chainMan.Td()
+
+	db.fail = true
+	err := chainMan.InsertChain(chain[10:20])
+	if !IsWriteErr(err) {
+		t.Fatalf("expected write error, got %v", err)
+	}
+	if werr := err.(*WriteErr); werr.Number.Cmp(chain[10].Number) != 0 {
+		t.Errorf("expected failing block #%v, got #%v", chain[10].Number, werr.Number)
+	}
+	if chainMan.CurrentBlock() != head || chainMan.Td().Cmp(td) != 0 {
+		t.Errorf("head moved after failed write: #%v", chainMan.CurrentBlock().Number)
+	}
+	if chainMan.HasBlock(chain[10].Hash()) {
+		t.Error("failed block was persisted")
+	}
+
+	db.fail = false
+	if err := chainMan.InsertChain(chain[10:20]); err != nil {
+		t.Fatal(err)
+	}
+	if chainMan.CurrentBlock().Number.Cmp(chain[19].Number) != 0 {
+		t.Errorf("expected head #%v, got #%v", chain[19].Number, chainMan.CurrentBlock().Number)
+	}
+}
+
+func TestChainForkedDatabase(t *testing.T) {
+	chain1 := loadChain("chain1", t)
+	chain2 := loadChain("chain2", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db1, _ := ethdb.NewMemDatabase()
+	newChainManagerWithDb(db1)
+	db2 := db1.Copy()
+
+	chainMan1 := newChainManagerWithDb(db1)
+	if err := chainMan1.InsertChain(chain1[:20]); err != nil {
+		t.Fatal(err)
+	}
+
+	chainMan2 := newChainManagerWithDb(db2)
+	if err := chainMan2.InsertChain(chain2[:20]); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(chainMan1.CurrentBlock().Hash(), chain1[19].Hash()) {
+		t.Errorf("chain1: unexpected head #%v", chainMan1.CurrentBlock().Number)
+	}
+	if !bytes.Equal(chainMan2.CurrentBlock().Hash(), chain2[19].Hash()) {
+		t.Errorf("chain2: unexpected head #%v", chainMan2.CurrentBlock().Number)
+	}
+	if chainMan2.HasBlock(chain1[19].Hash()) {
+		t.Error("forked database contains a block of the original chain")
+	}
+}
+
+func TestChainReorg(t *testing.T) {
+	chain1 := loadChain("chain1", t)
+	chain2 := loadChain("chain2", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain1[:10]); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		splits []ChainSplitEvent
+		heads  []*types.Block
+		done   = make(chan struct{})
+		sub    = chainMan.eventMux.Subscribe(ChainSplitEvent{}, ChainHeadEvent{})
+	)
+	go func() {
+		for ev := range sub.Chan() {
+			switch ev := ev.(type) {
+			case ChainSplitEvent:
+				splits = append(splits, ev)
+			case ChainHeadEvent:
+				heads = append(heads, ev.Block)
+			}
+		}
+		close(done)
+	}()
+
+	// chain2 is heavier than chain1 at the same height
+	if err := chainMan.InsertChain(chain2[:20]); err != nil {
+		t.Fatal(err)
+	}
+	sub.Unsubscribe()
+	<-done
+
+	if !bytes.Equal(chainMan.CurrentBlock().Hash(), chain2[19].Hash()) {
+		t.Fatalf("expected head #%v of chain2, got #%v", chain2[19].Number, chainMan.CurrentBlock().Number)
+	}
+
+	if len(splits) != 1 {
+		t.Fatalf("expected 1 split event, got %d", len(splits))
+	}
+	split := splits[0]
+	if !bytes.Equal(split.Ancestor.Hash(), chain1[0].Hash()) {
+		t.Errorf("expected genesis as common ancestor, got #%v", split.Ancestor.Number)
+	}
+	if len(split.OldChain) != 9 || !bytes.Equal(split.OldChain[0].Hash(), chain1[9].Hash()) {
+		t.Errorf("expected blocks #9 - #1 of chain1 to be replaced, got %d blocks", len(split.OldChain))
+	}
+	if n := len(split.NewChain); n == 0 || !bytes.Equal(split.NewChain[n-1].Hash(), chain2[1].Hash()) || !bytes.Equal(split.NewChain[0].Hash(), split.Block.Hash()) {
+		t.Error("expected the new chain to lead from chain2's block #1 to the new head")
+	}
+
+	if len(heads) == 0 || !bytes.Equal(heads[len(heads)-1].Hash(), chain2[19].Hash()) {
+		t.Error("expected a head event for the new head")
+	}
+	// The number index follows the new canonical chain
+	for i, block := range chain2[:20] {
+		if hash, _ := db.Get(blockNumKey(uint64(i))); !bytes.Equal(hash, block.Hash()) {
+			t.Errorf("number index #%d: expected %x, got %x", i, block.Hash()[:4], hash)
+		}
+	}
+}
+
+func TestChainGetBlockByNumber(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:20]); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, block := range chain[:20] {
+		if b := chainMan.GetBlockByNumber(uint64(i)); b == nil || !bytes.Equal(b.Hash(), block.Hash()) {
+			t.Errorf("block #%d: not found by number", i)
+		}
+	}
+	if b := chainMan.GetBlockByNumber(20); b != nil {
+		t.Errorf("expected no block #20, got %x", b.Hash()[:4])
+	}
+}
+
+func TestChainStoredMessages(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:10]); err != nil {
+		t.Fatal(err)
+	}
+
+	blockMan := chainMan.processor.(*BlockManager)
+	for i, block := range chain[1:10] {
+		if receipts := chainMan.GetReceipts(block.Hash()); receipts == nil || len(receipts) != len(block.Transactions()) {
+			t.Errorf("block #%d: expected %d stored receipts, got %v", i+1, len(block.Transactions()), receipts)
+		}
+
+		stored, ok := chainMan.GetMessages(block.Hash())
+		if !ok {
+			t.Errorf("block #%d: no stored messages", i+1)
+			continue
+		}
+		replayed, err := blockMan.GetMessages(block)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(stored) != len(replayed) {
+			t.Errorf("block #%d: expected %d messages, got %d", i+1, len(replayed), len(stored))
+			continue
+		}
+		for j, msg := range stored {
+			exp := replayed[j]
+			if !bytes.Equal(msg.To, exp.To) || !bytes.Equal(msg.Block, exp.Block) || msg.Number.Cmp(exp.Number) != 0 || msg.Value.Cmp(exp.Value) != 0 {
+				t.Errorf("block #%d message %d: expected %v, got %v", i+1, j, exp, msg)
+			}
+		}
+	}
+
+	if _, ok := chainMan.GetMessages(chain[10].Hash()); ok {
+		t.Errorf("expected no messages for a block which wasn't inserted")
+	}
+}
+
+func TestChainTxLookup(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+
+	txs := types.Transactions{
+		types.NewTransactionMessage([]byte("recipient1"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil),
+		types.NewTransactionMessage([]byte("recipient2"), big.NewInt(2), big.NewInt(21000), big.NewInt(1), nil),
+	}
+	block := types.CreateBlock(nil, chainMan.Genesis().Hash(), []byte("coinbase"), big.NewInt(1), nil, "")
+	block.Number = big.NewInt(1)
+	block.SetTransactions(txs)
+	receipts := types.Receipts{types.NewReceipt([]byte("root1"), big.NewInt(21000)), types.NewReceipt([]byte("root2"), big.NewInt(42000))}
+
+	batch := db.NewBatch()
+	chainMan.write(batch, block, big.NewInt(1))
+	chainMan.writeCanonical(batch, types.Blocks{block}, nil)
+	writeReceipts(batch, block, receipts, nil)
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tx := range txs {
+		found, b, index := chainMan.GetTransaction(tx.Hash())
+		if found == nil || !bytes.Equal(found.Hash(), tx.Hash()) {
+			t.Errorf("tx %d: not found", i)
+			continue
+		}
+		if !bytes.Equal(b.Hash(), block.Hash()) || index != uint64(i) {
+			t.Errorf("tx %d: expected block %x index %d, got block %x index %d", i, block.Hash()[:4], i, b.Hash()[:4], index)
+		}
+
+		receipt, b, index := chainMan.GetReceipt(tx.Hash())
+		if receipt == nil || !receipt.Cmp(receipts[i]) {
+			t.Errorf("tx %d: expected receipt %v, got %v", i, receipts[i], receipt)
+		} else if !bytes.Equal(b.Hash(), block.Hash()) || index != uint64(i) {
+			t.Errorf("tx %d: expected receipt of block %x index %d, got block %x index %d", i, block.Hash()[:4], i, b.Hash()[:4], index)
+		}
+
+		found, receipt, b, index = chainMan.GetTransactionReceipt(tx.Hash())
+		if found == nil || !bytes.Equal(found.Hash(), tx.Hash()) || receipt == nil || !receipt.Cmp(receipts[i]) {
+			t.Errorf("tx %d: expected tx and receipt %v, got %v and %v", i, receipts[i], found, receipt)
+		} else if !bytes.Equal(b.Hash(), block.Hash()) || index != uint64(i) {
+			t.Errorf("tx %d: expected block %x index %d, got block %x index %d", i, block.Hash()[:4], i, b.Hash()[:4], index)
+		}
+	}
+
+	// Transactions of blocks which are no longer canonical can't be found
+	batch = db.NewBatch()
+	deleteTxLookups(batch, txs[:1])
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+	if tx, _, _ := chainMan.GetTransaction(txs[0].Hash()); tx != nil {
+		t.Errorf("expected removed tx to be gone")
+	}
+	if receipt, _, _ := chainMan.GetReceipt(txs[0].Hash()); receipt != nil {
+		t.Errorf("expected no receipt for removed tx, got %v", receipt)
+	}
+	if tx, receipt, _, _ := chainMan.GetTransactionReceipt(txs[0].Hash()); tx != nil || receipt != nil {
+		t.Errorf("expected removed tx and its receipt to be gone")
+	}
+}
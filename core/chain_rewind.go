@@ -0,0 +1,113 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+// Rewinds the canonical chain to the block with the given number, which becomes the head again along with
+// its total difficulty and state. The blocks above it are deleted, together with their block info, receipts,
+// messages, canonical number index and transaction lookup entries, so they are processed again if they are
+// inserted later on. Their state is left to PruneState.
+//
+// A ChainSplitEvent with the removed blocks as OldChain (and no NewChain) is posted, followed by a
+// ChainHeadEvent for the new head. Returns an error if the block is unknown, above the current head or if
+// its state is missing from the database (e.g. because it was pruned).
+func (self *ChainManager) SetHead(number uint64) error {
+	block, removed, txs, err := self.setHead(number)
+	if err != nil {
+		return err
+	}
+
+	chainlogger.Infof("Rewound chain to #%d (%x), removed %d blocks\n", number, block.Hash()[:4], len(removed))
+
+	if len(removed) > 0 {
+		self.eventMux.Post(ChainSplitEvent{block, block, removed, nil, txs})
+	}
+	self.eventMux.Post(ChainHeadEvent{block})
+
+	return nil
+}
+
+// Inner function that rewinds the chain for SetHead. Returns the new head, the removed blocks (highest block
+// first) and their transactions (oldest first).
+func (self *ChainManager) setHead(number uint64) (*types.Block, types.Blocks, types.Transactions, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	head := self.currentBlock.Number.Uint64()
+	if number > head {
+		return nil, nil, nil, fmt.Errorf("can't rewind to #%d, head is #%d", number, head)
+	}
+
+	block := self.GetBlockByNumber(number)
+	if block == nil {
+		return nil, nil, nil, fmt.Errorf("block #%d missing from database", number)
+	}
+	if root := block.State().Root(); !bytes.Equal(root, EmptyListRoot) {
+		if data, _ := ethutil.Config.Db.Get(root); len(data) == 0 {
+			return nil, nil, nil, fmt.Errorf("state of block #%d (root %x) missing from database", number, root)
+		}
+	}
+
+	var (
+		removed types.Blocks
+		txs     types.Transactions
+		batch   = ethutil.Config.Db.NewBatch()
+	)
+	for num := head; num > number; num-- {
+		old := self.GetBlockByNumber(num)
+		if old == nil {
+			return nil, nil, nil, fmt.Errorf("block #%d missing from database", num)
+		}
+		removed = append(removed, old)
+
+		if err := deleteBlock(batch, old); err != nil {
+			return nil, nil, nil, err
+		}
+		if err := batch.Delete(blockNumKey(num)); err != nil {
+			return nil, nil, nil, err
+		}
+	}
+	for i := len(removed) - 1; i >= 0; i-- {
+		txs = append(txs, removed[i].Transactions()...)
+	}
+	if err := deleteTxLookups(batch, txs); err != nil {
+		return nil, nil, nil, err
+	}
+
+	td := block.BlockInfo().TD
+	if err := self.setTotalDifficulty(batch, td); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := self.insert(batch, block); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := batch.Write(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	self.td = td
+	self.currentBlock = block
+	self.lastBlockHash = block.Hash()
+	self.lastBlockNumber = number
+	self.transState = block.State().Copy()
+
+	return block, removed, txs, nil
+}
+
+// Inner function that queues the removal of the block, its block info, receipts and messages on the given
+// batch.
+func deleteBlock(batch ethutil.Batch, block *types.Block) error {
+	hash := block.Hash()
+	for _, key := range [][]byte{hash, append(hash, []byte("Info")...), receiptsKey(hash), messagesKey(hash)} {
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
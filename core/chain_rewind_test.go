@@ -0,0 +1,62 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestChainSetHead(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+	head := uint64(len(chain) - 1)
+
+	if err := chainMan.SetHead(head + 1); err == nil {
+		t.Error("expected error rewinding above the head")
+	}
+
+	if err := chainMan.SetHead(5); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(chainMan *ChainManager) {
+		if !bytes.Equal(chainMan.CurrentBlock().Hash(), chain[5].Hash()) {
+			t.Errorf("head is #%v, expected #5", chainMan.CurrentBlock().Number)
+		}
+		if td := chain[5].BlockInfo().TD; chainMan.Td().Cmp(td) != 0 {
+			t.Errorf("td mismatch: got %v, expected %v", chainMan.Td(), td)
+		}
+		if !bytes.Equal(chainMan.State().Root(), chain[5].State().Root()) {
+			t.Errorf("state root mismatch: got %x, expected %x", chainMan.State().Root(), chain[5].State().Root())
+		}
+		for num := uint64(6); num <= head; num++ {
+			if block := chainMan.GetBlockByNumber(num); block != nil {
+				t.Errorf("block #%d still in the number index", num)
+			}
+			if chainMan.HasBlock(chain[num].Hash()) {
+				t.Errorf("block #%d still in the database", num)
+			}
+		}
+	}
+	check(chainMan)
+
+	// The rewind must be persisted
+	check(newChainManagerWithDb(db))
+
+	// Removed blocks are processed again
+	if err := chainMan.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+	if num := chainMan.CurrentBlock().Number.Uint64(); num != head {
+		t.Errorf("head is #%d after reinsertion, expected #%d", num, head)
+	}
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"bytes"
+	"runtime"
+	"sync"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+)
+
+// Number of goroutines verifying blocks ahead of processing them during
+// InsertChain, one core is left to processing. Zero turns verification ahead
+// of processing off.
+var verifyWorkers = runtime.NumCPU() - 1
+
+// Implemented by block processors which can verify the blocks of a chain
+// ahead of processing them (see BlockManager.VerifyChain).
+type chainVerifier interface {
+	VerifyChain(chain types.Blocks) *ChainVerification
+}
+
+// A chain of blocks being verified ahead of processing, see
+// BlockManager.VerifyChain.
+//
+// errs: The result of verifying each block of the chain, available once the block's done channel is closed.
+//
+// hashes: The hashes of the blocks whose header was verified, these are forgotten again on Stop.
+type ChainVerification struct {
+	sm   *BlockManager
+	errs []error
+	done []chan struct{}
+	quit chan struct{}
+	wg   sync.WaitGroup
+
+	mu     sync.Mutex
+	hashes [][]byte
+}
+
+// Starts verifying the blocks of the chain on verifyWorkers goroutines. For every block the header is
+// validated against its parent (see ValidateHeader, which includes the Engine's check of the seal) and the
+// senders of its transactions are recovered, so neither has to be done while the block is processed.
+//
+// Blocks are handed to the workers in order, so the result of the first block is usually available
+// first. Blocks whose parent is neither the previous block of the chain nor known yield no error; they
+// are left to Process, which reports the missing parent. Uncles are validated while processing.
+//
+// The caller must call Stop once it's done with the verification.
+func (sm *BlockManager) VerifyChain(chain types.Blocks) *ChainVerification {
+	self := &ChainVerification{
+		sm:   sm,
+		errs: make([]error, len(chain)),
+		done: make([]chan struct{}, len(chain)),
+		quit: make(chan struct{}),
+	}
+	for i := range self.done {
+		self.done[i] = make(chan struct{})
+	}
+
+	jobs := make(chan int)
+	go func() {
+		defer close(jobs)
+
+		for i := range chain {
+			select {
+			case jobs <- i:
+			case <-self.quit:
+				return
+			}
+		}
+	}()
+
+	workers := verifyWorkers
+	if workers > len(chain) {
+		workers = len(chain)
+	}
+	self.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer self.wg.Done()
+
+			for i := range jobs {
+				var parent *types.Block
+				if i > 0 && bytes.Equal(chain[i-1].Hash(), chain[i].PrevHash) {
+					parent = chain[i-1]
+				} else {
+					parent = sm.bc.GetBlock(chain[i].PrevHash)
+				}
+
+				self.errs[i] = self.verify(chain[i], parent)
+				close(self.done[i])
+			}
+		}()
+	}
+
+	return self
+}
+
+// Verifies a single block. The block is marked as verified (see BlockManager.ValidateBlock) if its
+// header is valid.
+func (self *ChainVerification) verify(block, parent *types.Block) error {
+	for _, tx := range block.Transactions() {
+		tx.Sender()
+	}
+
+	if parent == nil {
+		return nil
+	}
+	if err := self.sm.ValidateHeader(block, parent); err != nil {
+		return err
+	}
+
+	hash := block.Hash()
+	self.sm.markVerified(hash)
+
+	self.mu.Lock()
+	self.hashes = append(self.hashes, hash)
+	self.mu.Unlock()
+
+	return nil
+}
+
+// Waits for the verification of the i-th block of the chain and returns its result. The verification of
+// the next block, which reads the i-th block as its parent, is waited for as well, so the i-th block may
+// be processed (and changed) once Wait returns.
+func (self *ChainVerification) Wait(i int) error {
+	<-self.done[i]
+	if i+1 < len(self.done) {
+		<-self.done[i+1]
+	}
+
+	return self.errs[i]
+}
+
+// Stops verifying the remaining blocks and forgets the verified headers of the blocks which weren't
+// processed.
+func (self *ChainVerification) Stop() {
+	close(self.quit)
+	self.wg.Wait()
+
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	for _, hash := range self.hashes {
+		self.sm.takeVerified(hash)
+	}
+}
+
+// Marks the header of the block with the given hash as verified
+func (sm *BlockManager) markVerified(hash []byte) {
+	sm.verifiedMu.Lock()
+	defer sm.verifiedMu.Unlock()
+
+	sm.verified[string(hash)] = true
+}
+
+// Returns whether the header of the block with the given hash was verified and forgets the mark
+func (sm *BlockManager) takeVerified(hash []byte) bool {
+	sm.verifiedMu.Lock()
+	defer sm.verifiedMu.Unlock()
+
+	verified := sm.verified[string(hash)]
+	delete(sm.verified, string(hash))
+
+	return verified
+}
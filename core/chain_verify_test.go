@@ -0,0 +1,82 @@
+package core
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestInsertChainVerifyFailure(t *testing.T) {
+	chain := loadChain("chain1", t)[:20]
+
+	defer func(prev int) { verifyWorkers = prev }(verifyWorkers)
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	verifyWorkers = 2
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	blockMan := chainMan.processor.(*BlockManager)
+
+	chain[10] = copyBlock(chain[10])
+	chain[10].Nonce = badNonce
+
+	err := chainMan.InsertChain(chain[1:])
+	if !IsValidationErr(err) || err.(*ValidationErr).Reason != InvalidNonce {
+		t.Fatalf("expected invalid nonce error, got %v", err)
+	}
+	if num := chainMan.CurrentBlock().Number.Uint64(); num != 9 {
+		t.Errorf("head is #%d, expected #9", num)
+	}
+	if len(blockMan.verified) != 0 {
+		t.Errorf("%d verified headers not forgotten", len(blockMan.verified))
+	}
+}
+
+func TestInsertChainVerifyKnown(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev int) { verifyWorkers = prev }(verifyWorkers)
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	verifyWorkers = 2
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	blockMan := chainMan.processor.(*BlockManager)
+
+	if err := chainMan.InsertChain(chain[:20]); err != nil {
+		t.Fatal(err)
+	}
+	// Known blocks are skipped, the rest is verified and processed
+	if err := chainMan.InsertChain(chain); err != nil {
+		t.Fatal(err)
+	}
+	if head := chainMan.CurrentBlock(); head.Number.Uint64() != uint64(len(chain)-1) {
+		t.Errorf("head is #%v, expected #%d", head.Number, len(chain)-1)
+	}
+	if len(blockMan.verified) != 0 {
+		t.Errorf("%d verified headers not forgotten", len(blockMan.verified))
+	}
+}
+
+func benchmarkInsertChain(b *testing.B, workers int) {
+	defer func(prev int) { verifyWorkers = prev }(verifyWorkers)
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	verifyWorkers = workers
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		chain := loadChain("chain1", b)
+		db, _ := ethdb.NewMemDatabase()
+		chainMan := newChainManagerWithDb(db)
+		b.StartTimer()
+
+		if err := chainMan.InsertChain(chain); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsertChainSerial(b *testing.B)   { benchmarkInsertChain(b, 0) }
+func BenchmarkInsertChainParallel(b *testing.B) { benchmarkInsertChain(b, runtime.NumCPU()) }
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/pow"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+)
+
+// The consensus rules of a chain. The BlockManager uses an Engine to validate and finalize blocks and the
+// miner uses it to prepare and seal new blocks.
+//
+// VerifyHeader: Checks the engine specific fields of the header of a block (or an uncle), its difficulty
+// and its seal, against the header of its parent. The generic checks are done by BlockManager.ValidateHeader.
+//
+// Prepare: Sets the engine specific fields of a new block, which is about to be mined on top of parent.
+//
+// Finalize: Credits the rewards of the block on statedb once the block's transactions have been applied.
+//
+// Seal: Returns a nonce which seals the block, or nil if stop was closed first.
+type Engine interface {
+	VerifyHeader(block, parent *types.Block) error
+	Prepare(block, parent *types.Block)
+	Finalize(chain *ChainManager, statedb *state.StateDB, block, parent *types.Block) error
+	Seal(block *types.Block, stop <-chan struct{}) []byte
+}
+
+// The default Engine: the difficulty follows CalcDifficulty, blocks are sealed by the proof of work and
+// rewarded by AccumulateRewards.
+type PowEngine struct {
+	pow.PoW
+}
+
+func NewPowEngine(pow pow.PoW) *PowEngine {
+	return &PowEngine{pow}
+}
+
+func (self *PowEngine) VerifyHeader(block, parent *types.Block) error {
+	expd := CalcDifficulty(block, parent)
+	if expd.Cmp(block.Difficulty) < 0 {
+		return ValidationError(InvalidDifficulty, "Difficulty check failed for block %v, %v", block.Difficulty, expd)
+	}
+
+	// Verify the nonce of the block. Return an error if it's not valid
+	if !self.Verify(block /*block.HashNoNonce(), block.Difficulty, block.Nonce*/) {
+		return ValidationError(InvalidNonce, "Block's nonce is invalid (= %v)", ethutil.Bytes2Hex(block.Nonce))
+	}
+
+	return nil
+}
+
+func (self *PowEngine) Prepare(block, parent *types.Block) {
+	block.Difficulty = CalcDifficulty(block, parent)
+}
+
+func (self *PowEngine) Finalize(chain *ChainManager, statedb *state.StateDB, block, parent *types.Block) error {
+	return AccumulateRewards(chain, statedb, block, parent)
+}
+
+func (self *PowEngine) Seal(block *types.Block, stop <-chan struct{}) []byte {
+	return self.Search(block, stop)
+}
+
+// An Engine for local development chains which seals blocks without doing any work. Blocks keep the
+// difficulty of their parent and are sealed with the hash of their header, as soon as they include
+// transactions; empty blocks are never sealed, so an idle chain doesn't grow. Rewards follow
+// AccumulateRewards.
+type InstantSealEngine struct{}
+
+func NewInstantSealEngine() *InstantSealEngine {
+	return &InstantSealEngine{}
+}
+
+func (self *InstantSealEngine) VerifyHeader(block, parent *types.Block) error {
+	if block.Difficulty.Cmp(parent.Difficulty) != 0 {
+		return ValidationError(InvalidDifficulty, "Difficulty check failed for block %v, %v", block.Difficulty, parent.Difficulty)
+	}
+
+	if !bytes.Equal(block.Nonce, crypto.Sha3(block.HashNoNonce())) {
+		return ValidationError(InvalidNonce, "Block's nonce is invalid (= %v)", ethutil.Bytes2Hex(block.Nonce))
+	}
+
+	return nil
+}
+
+func (self *InstantSealEngine) Prepare(block, parent *types.Block) {
+	block.Difficulty = new(big.Int).Set(parent.Difficulty)
+}
+
+func (self *InstantSealEngine) Finalize(chain *ChainManager, statedb *state.StateDB, block, parent *types.Block) error {
+	return AccumulateRewards(chain, statedb, block, parent)
+}
+
+func (self *InstantSealEngine) Seal(block *types.Block, stop <-chan struct{}) []byte {
+	if len(block.Transactions()) == 0 {
+		<-stop
+
+		return nil
+	}
+
+	return crypto.Sha3(block.HashNoNonce())
+}
+
+// Calculates the reward of the miner. Returns an error if an error has occured during the
+// validation process. If no errors have occured, nil is returned.
+//
+// More specifically an error is returned:
+// 1. if the parent of any of the uncles of the 'block' is nil, or
+//
+// 2. if the (block) number of the parent of any of the uncles of the 'block' and the 'block' itself have a difference greater than 6, or
+//
+// 3. if the hash of any of the uncles of the param 'block' matches any of the uncles of the param 'parent'.
+//
+// 4. if the nonce of any of the uncles of the param 'block' is included in the nonce of the 'block'
+//
+// The reward to be appointed to the miner will be:
+//
+// If the 'block' has 1 uncle: r1 = BlockReward + BlockReward/32,
+//
+// If the 'block' has 2 uncles: r2 = r1 + r1/32, etc., where BlockReward = 1.5 Ether,( defined in the core package, file fees.go)
+//
+// Finally, a message is added to the state manifest regarding the value to be transferred to the miner address.
+// This value will be the sum of the above calculated reward and the block.Reward.
+func AccumulateRewards(chain *ChainManager, statedb *state.StateDB, block, parent *types.Block) error {
+	reward := new(big.Int).Set(BlockReward)
+
+	knownUncles := ethutil.Set(parent.Uncles)
+	nonces := ethutil.NewSet(block.Nonce)
+	for _, uncle := range block.Uncles {
+		if nonces.Include(uncle.Nonce) {
+			// Error not unique
+			return UncleError("Uncle not unique")
+		}
+
+		uncleParent := chain.GetBlock(uncle.PrevHash)
+		if uncleParent == nil {
+			return UncleError(fmt.Sprintf("Uncle's parent unknown (%x)", uncle.PrevHash[0:4]))
+		}
+
+		if uncleParent.Number.Cmp(new(big.Int).Sub(parent.Number, big.NewInt(6))) < 0 {
+			return UncleError("Uncle too old")
+		}
+
+		if knownUncles.Include(uncle.Hash()) {
+			return UncleError("Uncle in chain")
+		}
+
+		nonces.Insert(uncle.Nonce)
+
+		r := new(big.Int)
+		r.Mul(BlockReward, big.NewInt(15)).Div(r, big.NewInt(16))
+
+		uncleAccount := statedb.GetAccount(uncle.Coinbase)
+		uncleAccount.AddAmount(r)
+
+		reward.Add(reward, new(big.Int).Div(BlockReward, big.NewInt(32)))
+	}
+
+	// Get the account associated with the coinbase
+	account := statedb.GetAccount(block.Coinbase)
+	// Reward amount of ether to the coinbase address
+	account.AddAmount(reward)
+
+	statedb.Manifest().AddMessage(&state.Message{
+		To:     block.Coinbase,
+		Input:  nil,
+		Origin: nil,
+		Block:  block.Hash(), Timestamp: block.Time, Coinbase: block.Coinbase, Number: block.Number,
+		Value: new(big.Int).Add(reward, block.Reward),
+	})
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestInstantSealEngine(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:5]); err != nil {
+		t.Fatal(err)
+	}
+	parent := chainMan.CurrentBlock()
+
+	engine := NewInstantSealEngine()
+	block := chainMan.NewBlock([]byte("coinbase"))
+	engine.Prepare(block, parent)
+	if block.Difficulty.Cmp(parent.Difficulty) != 0 {
+		t.Errorf("expected difficulty %v, got %v", parent.Difficulty, block.Difficulty)
+	}
+
+	// Empty blocks are only given up on
+	stop := make(chan struct{})
+	close(stop)
+	if nonce := engine.Seal(block, stop); nonce != nil {
+		t.Errorf("expected empty block not to be sealed, got nonce %x", nonce)
+	}
+
+	block.SetTransactions(types.Transactions{types.NewTransactionMessage([]byte("recipient"), ethutil.Big1, big.NewInt(21000), ethutil.Big1, nil)})
+	block.Nonce = engine.Seal(block, nil)
+	if err := engine.VerifyHeader(block, parent); err != nil {
+		t.Errorf("expected sealed block to verify, got %v", err)
+	}
+
+	block.Difficulty = new(big.Int).Add(block.Difficulty, ethutil.Big1)
+	if err := engine.VerifyHeader(block, parent); !IsValidationErr(err) || err.(*ValidationErr).Reason != InvalidDifficulty {
+		t.Errorf("expected difficulty error, got %v", err)
+	}
+	block.Difficulty = parent.Difficulty
+
+	block.Nonce = make([]byte, 32)
+	if err := engine.VerifyHeader(block, parent); !IsValidationErr(err) || err.(*ValidationErr).Reason != InvalidNonce {
+		t.Errorf("expected nonce error, got %v", err)
+	}
+}
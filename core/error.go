@@ -28,8 +28,8 @@ func IsParentErr(err error) bool {
 	return ok
 }
 
-// Uncle error. This error is thrown only from the function BlockManager.AccumelateRewards defined in the 
-// 'core' package (file block_manager.go). See that function for more.
+// Uncle error. This error is thrown from the functions BlockManager.ValidateBlock and AccumulateRewards defined in the
+// 'core' package (files block_manager.go and consensus.go). See those functions for more.
 type UncleErr struct {
 	Message string
 }
@@ -51,8 +51,54 @@ func IsUncleErr(err error) bool {
 	return ok
 }
 
-// Block validation error. If any validation fails, this error will be thrown
+// The check of the block header a ValidationErr failed on.
+type ValidationReason byte
+
+const (
+	InvalidNumber ValidationReason = iota
+	InvalidDifficulty
+	InvalidTimestamp
+	FutureBlock
+	InvalidGasLimit
+	InvalidGasUsed
+	InvalidExtraData
+	InvalidNonce
+	InvalidUncle
+	InvalidTxSha
+	InvalidUncleSha
+	InvalidBloom
+	InvalidReceiptSha
+	InvalidStateRoot
+)
+
+var validationReasonToString = []string{
+	"invalid number",
+	"invalid difficulty",
+	"invalid timestamp",
+	"block in the future",
+	"invalid gas limit",
+	"invalid gas used",
+	"extra data too long",
+	"invalid nonce",
+	"invalid uncle",
+	"invalid transaction root",
+	"invalid uncle root",
+	"invalid bloom",
+	"invalid receipt root",
+	"invalid state root",
+}
+
+func (r ValidationReason) String() string {
+	if len(validationReasonToString) <= int(r) {
+		return "Unknown"
+	}
+
+	return validationReasonToString[r]
+}
+
+// Block validation error. If any validation fails, this error will be thrown. Reason tells which check failed.
 type ValidationErr struct {
+	Reason  ValidationReason
 	Message string
 }
 
@@ -61,9 +107,9 @@ func (err *ValidationErr) Error() string {
 	return err.Message
 }
 
-// Creates a ValidationErr error by setting it's message and returns it.
-func ValidationError(format string, v ...interface{}) *ValidationErr {
-	return &ValidationErr{Message: fmt.Sprintf(format, v...)}
+// Creates a ValidationErr error by setting it's reason and message and returns it.
+func ValidationError(reason ValidationReason, format string, v ...interface{}) *ValidationErr {
+	return &ValidationErr{Reason: reason, Message: fmt.Sprintf(format, v...)}
 }
 
 // Returns whether 'err' is a ValidationErr error.
@@ -141,6 +187,76 @@ func IsOutOfGasErr(err error) bool {
 	return ok
 }
 
+// Happens when the balance of a transaction's sender doesn't cover the value of the transaction plus
+// its gas times its gas price.
+type InsufficientFundsErr struct {
+	Message       string
+	Balance, Cost *big.Int
+}
+
+// Returns the error message of an InsufficientFundsErr error.
+func (err *InsufficientFundsErr) Error() string {
+	return err.Message
+}
+
+// Creates and returns an InsufficientFundsErr error given the sender's balance and the cost of the transaction.
+func InsufficientFundsError(balance, cost *big.Int) *InsufficientFundsErr {
+	return &InsufficientFundsErr{Message: fmt.Sprintf("Insufficient funds. Balance %v, transaction costs %v", balance, cost), Balance: balance, Cost: cost}
+}
+
+// Returns whether 'err' is an InsufficientFundsErr error.
+func IsInsufficientFundsErr(err error) bool {
+	_, ok := err.(*InsufficientFundsErr)
+
+	return ok
+}
+
+// Happens when the gas of a transaction doesn't cover its intrinsic gas (see IntrinsicGas).
+type IntrinsicGasErr struct {
+	Message       string
+	Gas, Required *big.Int
+}
+
+// Returns the error message of an IntrinsicGasErr error.
+func (err *IntrinsicGasErr) Error() string {
+	return err.Message
+}
+
+// Creates and returns an IntrinsicGasErr error given the gas of the transaction and its intrinsic gas.
+func IntrinsicGasError(gas, required *big.Int) *IntrinsicGasErr {
+	return &IntrinsicGasErr{Message: fmt.Sprintf("Intrinsic gas too low. Has %v, requires %v", gas, required), Gas: gas, Required: required}
+}
+
+// Returns whether 'err' is an IntrinsicGasErr error.
+func IsIntrinsicGasErr(err error) bool {
+	_, ok := err.(*IntrinsicGasErr)
+
+	return ok
+}
+
+// Happens when the data of a transaction is larger than the transaction pool accepts.
+type OversizedDataErr struct {
+	Message   string
+	Size, Max int
+}
+
+// Returns the error message of an OversizedDataErr error.
+func (err *OversizedDataErr) Error() string {
+	return err.Message
+}
+
+// Creates and returns an OversizedDataErr error given the size of the transaction's data and the maximum size.
+func OversizedDataError(size, max int) *OversizedDataErr {
+	return &OversizedDataErr{Message: fmt.Sprintf("Transaction data too large (%d > %d bytes)", size, max), Size: size, Max: max}
+}
+
+// Returns whether 'err' is an OversizedDataErr error.
+func IsOversizedDataErr(err error) bool {
+	_, ok := err.(*OversizedDataErr)
+
+	return ok
+}
+
 // Defined, but not used. Meant to be used when there is a total difficulty error, a < b.
 type TDError struct {
 	a, b *big.Int
@@ -157,6 +273,30 @@ func IsTDError(e error) bool {
 	return ok
 }
 
+// Happens when a block, or the state it produced, could not be persisted to the database.
+type WriteErr struct {
+	Number *big.Int
+	Hash   []byte
+	Err    error
+}
+
+// Returns the error message of a WriteErr error.
+func (err *WriteErr) Error() string {
+	return fmt.Sprintf("block #%v (%x) write failed: %v", err.Number, err.Hash[0:4], err.Err)
+}
+
+// Creates and returns a WriteErr error given the number and hash of the block and the database error.
+func WriteError(number *big.Int, hash []byte, err error) *WriteErr {
+	return &WriteErr{Number: number, Hash: hash, Err: err}
+}
+
+// Returns whether 'err' is a WriteErr error.
+func IsWriteErr(err error) bool {
+	_, ok := err.(*WriteErr)
+
+	return ok
+}
+
 // Happens when there is already a block in the chain with the same hash. The number field is the number of the existing block.
 type KnownBlockError struct {
 	number *big.Int
@@ -173,3 +313,28 @@ func IsKnownBlockErr(e error) bool {
 	_, ok := e.(*KnownBlockError)
 	return ok
 }
+
+// Happens when a block is known to be bad, or descends from a bad block. Reason holds the reason the
+// block was rejected. See ChainManager.BadBlocks.
+type BadBlockErr struct {
+	Number *big.Int
+	Hash   []byte
+	Reason string
+}
+
+// Returns the error message of a BadBlockErr error.
+func (err *BadBlockErr) Error() string {
+	return fmt.Sprintf("block #%v (%x) is bad: %s", err.Number, err.Hash[0:4], err.Reason)
+}
+
+// Creates and returns a BadBlockErr error given the number and hash of the block and the reason it was rejected.
+func BadBlockError(number *big.Int, hash []byte, reason string) *BadBlockErr {
+	return &BadBlockErr{Number: number, Hash: hash, Reason: reason}
+}
+
+// Returns whether 'err' is a BadBlockErr error.
+func IsBadBlockErr(err error) bool {
+	_, ok := err.(*BadBlockErr)
+
+	return ok
+}
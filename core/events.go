@@ -10,3 +10,19 @@ type TxPostEvent struct{ Tx *types.Transaction }
 
 // NewBlockEvent is posted when a block has been imported.
 type NewBlockEvent struct{ Block *types.Block }
+
+// ChainSplitEvent is posted when a block on a side chain becomes the head and
+// blocks of the canonical chain are replaced. OldChain holds the replaced
+// blocks and NewChain the blocks replacing them, both ordered from the highest
+// block down to the child of Ancestor, the last block both chains share.
+// Removed holds the transactions of OldChain which aren't part of NewChain.
+type ChainSplitEvent struct {
+	Block    *types.Block
+	Ancestor *types.Block
+	OldChain types.Blocks
+	NewChain types.Blocks
+	Removed  types.Transactions
+}
+
+// ChainHeadEvent is posted when a block becomes the head of the chain.
+type ChainHeadEvent struct{ Block *types.Block }
@@ -55,6 +55,11 @@ type Filter struct {
 	Altered         []AccountChange
 	BlockCallback   func(*types.Block)
 	MessageCallback func(state.Messages)
+
+	// Log filtering, see SetAddress and SetTopics
+	address      [][]byte
+	topics       [][][]byte
+	LogsCallback func(state.Logs)
 }
 
 // Create a new filter which uses a bloom filter on blocks
@@ -94,6 +99,24 @@ func (self *Filter) AddTo(addr []byte) {
 	self.to = append(self.to, addr)
 }
 
+// Sets the addresses of the contracts whose logs are matched. An empty set
+// matches logs of any address.
+func (self *Filter) SetAddress(addr [][]byte) {
+	self.address = addr
+}
+
+func (self *Filter) AddAddress(addr []byte) {
+	self.address = append(self.address, addr)
+}
+
+// Sets the topics the logs are matched against. Topics are positional: the
+// i-th topic of a log has to be one of topics[i]. An empty set matches any
+// topic at that position, logs with fewer topics than given positions never
+// match.
+func (self *Filter) SetTopics(topics [][][]byte) {
+	self.topics = topics
+}
+
 func (self *Filter) SetMax(max int) {
 	self.max = max
 }
@@ -115,13 +138,18 @@ func (self *Filter) Find() []*state.Message {
 
 	var (
 		messages []*state.Message
-		block    = self.eth.ChainManager().GetBlockByNumber(latestBlockNo)
+		chainMan = self.eth.ChainManager()
 		quit     bool
 	)
-	for i := 0; !quit && block != nil; i++ {
+	for num := latestBlockNo; !quit; num-- {
+		block := chainMan.GetBlockByNumber(num)
+		if block == nil {
+			break
+		}
+
 		// Quit on latest
 		switch {
-		case block.Number.Uint64() == earliestBlockNo, block.Number.Uint64() == 0:
+		case num == earliestBlockNo, num == 0:
 			quit = true
 		case self.max <= len(messages):
 			break
@@ -130,18 +158,20 @@ func (self *Filter) Find() []*state.Message {
 		// Use bloom filtering to see if this block is interesting given the
 		// current parameters
 		if self.bloomFilter(block) {
-			// Get the messages of the block
-			msgs, err := self.eth.BlockManager().GetMessages(block)
-			if err != nil {
-				chainlogger.Warnln("err: filter get messages ", err)
-
-				break
+			// Get the messages of the block. Blocks which were inserted before
+			// messages were stored have to be processed again
+			msgs, ok := chainMan.GetMessages(block.Hash())
+			if !ok {
+				var err error
+				if msgs, err = self.eth.BlockManager().GetMessages(block); err != nil {
+					chainlogger.Warnln("err: filter get messages ", err)
+
+					break
+				}
 			}
 
 			messages = append(messages, self.FilterMessages(msgs)...)
 		}
-
-		block = self.eth.ChainManager().GetBlock(block.PrevHash)
 	}
 
 	skip := int(math.Min(float64(len(messages)), float64(self.skip)))
@@ -149,6 +179,92 @@ func (self *Filter) Find() []*state.Message {
 	return messages[skip:]
 }
 
+// Returns the logs of the blocks in range which match the address and topics
+// of the filter, oldest first.
+func (self *Filter) FindLogs() state.Logs {
+	chainMan := self.eth.ChainManager()
+
+	var earliestBlockNo uint64 = uint64(self.earliest)
+	if self.earliest == -1 {
+		earliestBlockNo = chainMan.CurrentBlock().Number.Uint64()
+	}
+	var latestBlockNo uint64 = uint64(self.latest)
+	if self.latest == -1 {
+		latestBlockNo = chainMan.CurrentBlock().Number.Uint64()
+	}
+
+	var logs state.Logs
+	for num := earliestBlockNo; num <= latestBlockNo; num++ {
+		block := chainMan.GetBlockByNumber(num)
+		if block == nil {
+			break
+		}
+
+		if !self.logsBloomFilter(block) {
+			continue
+		}
+
+		// Blocks which were inserted before receipts were stored have to be
+		// processed again. Blocks without transactions have no logs at all
+		var blockLogs state.Logs
+		if receipts := chainMan.GetReceipts(block.Hash()); receipts != nil {
+			for _, receipt := range receipts {
+				blockLogs = append(blockLogs, receipt.Logs()...)
+			}
+		} else if len(block.Transactions()) > 0 {
+			var err error
+			if blockLogs, err = self.eth.BlockManager().GetLogs(block); err != nil {
+				chainlogger.Warnln("err: filter get logs ", err)
+
+				break
+			}
+		}
+
+		logs = append(logs, self.FilterLogs(blockLogs)...)
+		if self.max > 0 && len(logs) >= self.skip+self.max {
+			break
+		}
+	}
+
+	skip := int(math.Min(float64(len(logs)), float64(self.skip)))
+	logs = logs[skip:]
+	if self.max > 0 && len(logs) > self.max {
+		logs = logs[:self.max]
+	}
+
+	return logs
+}
+
+// Returns the logs which match the address and topics of the filter.
+func (self *Filter) FilterLogs(logs state.Logs) state.Logs {
+	var ret state.Logs
+
+	for _, log := range logs {
+		if len(self.address) > 0 && !includes(self.address, log.Address()) {
+			continue
+		}
+
+		topics := log.Topics()
+		if len(topics) < len(self.topics) {
+			continue
+		}
+
+		match := true
+		for i, set := range self.topics {
+			if len(set) > 0 && !includes(set, topics[i]) {
+				match = false
+				break
+			}
+		}
+
+		if match {
+			ret = append(ret, log)
+		}
+	}
+
+	return ret
+}
+
 func includes(addresses [][]byte, a []byte) (found bool) {
 	for _, addr := range addresses {
 		if bytes.Compare(addr, a) == 0 {
@@ -237,3 +353,30 @@ func (self *Filter) bloomFilter(block *types.Block) bool {
 
 	return fromIncluded && toIncluded
 }
+
+// Returns false if the block's bloom shows that none of its logs can match the
+// address and topics of the filter.
+func (self *Filter) logsBloomFilter(block *types.Block) bool {
+	if len(self.address) > 0 && !bloomIncludes(block.LogsBloom, self.address) {
+		return false
+	}
+
+	for _, set := range self.topics {
+		if len(set) > 0 && !bloomIncludes(block.LogsBloom, set) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Returns true if any of the values may be in the bloom.
+func bloomIncludes(bloom []byte, values [][]byte) bool {
+	for _, value := range values {
+		if types.BloomLookup(bloom, value) {
+			return true
+		}
+	}
+
+	return false
+}
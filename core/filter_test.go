@@ -1 +1,85 @@
 package core
+
+import (
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+)
+
+func TestFilterLogs(t *testing.T) {
+	var (
+		addr1, addr2 = []byte("address1"), []byte("address2")
+		topicA       = []byte("topicA")
+		topicB       = []byte("topicB")
+		topicC       = []byte("topicC")
+	)
+	logs := state.Logs{
+		state.NewLog(addr1, [][]byte{topicA}, nil),
+		state.NewLog(addr1, [][]byte{topicA, topicB}, nil),
+		state.NewLog(addr2, [][]byte{topicB, topicC}, nil),
+		state.NewLog(addr2, nil, nil),
+	}
+
+	tests := []struct {
+		address [][]byte
+		topics  [][][]byte
+		matches []int
+	}{
+		{nil, nil, []int{0, 1, 2, 3}},
+		{[][]byte{addr1}, nil, []int{0, 1}},
+		{[][]byte{addr1, addr2}, nil, []int{0, 1, 2, 3}},
+		{nil, [][][]byte{{topicA}}, []int{0, 1}},
+		{nil, [][][]byte{{topicA, topicB}}, []int{0, 1, 2}},
+		{nil, [][][]byte{nil, {topicB}}, []int{1}},
+		{nil, [][][]byte{nil, nil}, []int{1, 2}},
+		{[][]byte{addr2}, [][][]byte{{topicB}, {topicC}}, []int{2}},
+		{[][]byte{addr2}, [][][]byte{{topicA}}, nil},
+	}
+
+	for i, test := range tests {
+		filter := NewFilter(nil)
+		filter.SetAddress(test.address)
+		filter.SetTopics(test.topics)
+
+		matched := filter.FilterLogs(logs)
+		if len(matched) != len(test.matches) {
+			t.Errorf("test %d: expected %d logs, got %d", i, len(test.matches), len(matched))
+			continue
+		}
+		for j, idx := range test.matches {
+			if matched[j] != logs[idx] {
+				t.Errorf("test %d: expected log %d at position %d", i, idx, j)
+			}
+		}
+	}
+}
+
+func TestFilterLogsBloom(t *testing.T) {
+	receipt := types.NewReceipt(nil, nil)
+	receipt.SetLogs(state.Logs{state.NewLog([]byte("address1"), [][]byte{[]byte("topicA")}, nil)})
+	block := &types.Block{LogsBloom: types.CreateBloom(types.Receipts{receipt})}
+
+	tests := []struct {
+		address [][]byte
+		topics  [][][]byte
+		match   bool
+	}{
+		{nil, nil, true},
+		{[][]byte{[]byte("address1")}, nil, true},
+		{[][]byte{[]byte("address2")}, nil, false},
+		{[][]byte{[]byte("address2"), []byte("address1")}, nil, true},
+		{nil, [][][]byte{{[]byte("topicA")}}, true},
+		{nil, [][][]byte{nil, {[]byte("topicB")}}, false},
+	}
+
+	for i, test := range tests {
+		filter := NewFilter(nil)
+		filter.SetAddress(test.address)
+		filter.SetTopics(test.topics)
+
+		if match := filter.logsBloomFilter(block); match != test.match {
+			t.Errorf("test %d: expected bloom match %v, got %v", i, test.match, match)
+		}
+	}
+}
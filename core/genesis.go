@@ -1,8 +1,15 @@
 package core
 
 import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
 	"math/big"
+	"os"
 
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 )
@@ -71,3 +78,210 @@ var GenesisHeader = []interface{}{
 // header, transactions and uncles. The genesis block does not have any transactions and uncles, thus
 // the use of the 2 'empty' objects.
 var Genesis = []interface{}{GenesisHeader, []interface{}{}, []interface{}{}}
+
+// Key under which the hash of the genesis block the database was created with
+// is stored
+var genesisHashKey = []byte("GenesisHash")
+
+// Returns the default genesis block, including the test net funds (see
+// AddTestNetFunds).
+func DefaultGenesis() *types.Block {
+	genesis := types.NewBlockFromBytes(ethutil.Encode(Genesis))
+	AddTestNetFunds(genesis)
+
+	return genesis
+}
+
+// An account which is allocated in the genesis block. All values are hex
+// encoded (an optional 0x prefix is allowed), except the balance which may
+// also be given in decimal.
+type GenesisAccount struct {
+	Balance string            `json:"balance"`
+	Code    string            `json:"code"`
+	Storage map[string]string `json:"storage"`
+}
+
+// GenesisSpec describes a custom genesis block as read from a JSON file:
+//
+//	{
+//		"difficulty": "0x20000",
+//		"gasLimit":   "1000000",
+//		"timestamp":  "0",
+//		"extraData":  "0x",
+//		"coinbase":   "0x0000000000000000000000000000000000000000",
+//		"alloc": {
+//			"51ba59315b3a95761d0863b05ccc7a7f54703d99": {"balance": "1000000"}
+//		}
+//	}
+//
+// Numbers are decimal or 0x prefixed hex. Fields which are left out take the
+// value of the default genesis block, an empty alloc allocates nothing.
+type GenesisSpec struct {
+	Nonce      string                    `json:"nonce"`
+	Difficulty string                    `json:"difficulty"`
+	GasLimit   string                    `json:"gasLimit"`
+	Timestamp  string                    `json:"timestamp"`
+	ExtraData  string                    `json:"extraData"`
+	Coinbase   string                    `json:"coinbase"`
+	Alloc      map[string]GenesisAccount `json:"alloc"`
+}
+
+// Reads a genesis spec in JSON format from r.
+func ReadGenesis(r io.Reader) (*GenesisSpec, error) {
+	spec := new(GenesisSpec)
+	if err := json.NewDecoder(r).Decode(spec); err != nil {
+		return nil, fmt.Errorf("invalid genesis spec: %v", err)
+	}
+
+	return spec, nil
+}
+
+// Reads the genesis spec from the JSON file at path.
+func LoadGenesis(path string) (*GenesisSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadGenesis(file)
+}
+
+// Creates the genesis block described by the spec and allocates its accounts
+// in the block's state. The state is written to the database together with the
+// block by the chain manager.
+func (self *GenesisSpec) Block() (*types.Block, error) {
+	var (
+		err        error
+		nonce      = crypto.Sha3(big.NewInt(42).Bytes())
+		difficulty = big.NewInt(131072)
+		gasLimit   = big.NewInt(1000000)
+		timestamp  = ethutil.Big0
+		extra      []byte
+		coinbase   = ZeroHash160
+	)
+
+	if self.Nonce != "" {
+		if nonce, err = genesisHex(self.Nonce); err != nil {
+			return nil, fmt.Errorf("genesis nonce: %v", err)
+		}
+	}
+	if self.Difficulty != "" {
+		if difficulty, err = genesisBig(self.Difficulty); err != nil {
+			return nil, fmt.Errorf("genesis difficulty: %v", err)
+		}
+	}
+	if self.GasLimit != "" {
+		if gasLimit, err = genesisBig(self.GasLimit); err != nil {
+			return nil, fmt.Errorf("genesis gas limit: %v", err)
+		}
+	}
+	if self.Timestamp != "" {
+		if timestamp, err = genesisBig(self.Timestamp); err != nil {
+			return nil, fmt.Errorf("genesis timestamp: %v", err)
+		}
+	}
+	if extra, err = genesisHex(self.ExtraData); err != nil {
+		return nil, fmt.Errorf("genesis extra data: %v", err)
+	}
+	if self.Coinbase != "" {
+		if coinbase, err = genesisHex(self.Coinbase); err != nil || len(coinbase) != 20 {
+			return nil, fmt.Errorf("genesis coinbase: invalid address %q", self.Coinbase)
+		}
+	}
+
+	header := []interface{}{
+		ZeroHash256,
+		EmptyShaList,
+		coinbase,
+		EmptyShaList,
+		EmptyListRoot,
+		EmptyListRoot,
+		ZeroHash512,
+		difficulty,
+		ethutil.Big0,
+		gasLimit,
+		ethutil.Big0,
+		timestamp,
+		extra,
+		nonce,
+	}
+	genesis := types.NewBlockFromBytes(ethutil.Encode([]interface{}{header, []interface{}{}, []interface{}{}}))
+
+	statedb := genesis.State()
+	for addr, account := range self.Alloc {
+		address, err := genesisHex(addr)
+		if err != nil || len(address) != 20 {
+			return nil, fmt.Errorf("genesis alloc: invalid address %q", addr)
+		}
+
+		object := statedb.GetOrNewStateObject(address)
+		if account.Balance != "" {
+			balance, err := genesisBig(account.Balance)
+			if err != nil {
+				return nil, fmt.Errorf("genesis alloc %s: balance: %v", addr, err)
+			}
+			object.SetBalance(balance)
+		}
+
+		code, err := genesisHex(account.Code)
+		if err != nil {
+			return nil, fmt.Errorf("genesis alloc %s: code: %v", addr, err)
+		}
+		object.SetCode(code)
+
+		for key, value := range account.Storage {
+			k, err := genesisHex(key)
+			if err != nil {
+				return nil, fmt.Errorf("genesis alloc %s: storage key: %v", addr, err)
+			}
+			v, err := genesisHex(value)
+			if err != nil {
+				return nil, fmt.Errorf("genesis alloc %s: storage value: %v", addr, err)
+			}
+			object.SetState(k, ethutil.NewValue(v))
+		}
+	}
+	statedb.Update(nil)
+
+	return genesis, nil
+}
+
+// Makes sure the database belongs to the given genesis block. The genesis hash
+// is stored the first time a database is used and compared on every start
+// after that. Databases created before the hash was stored are checked
+// against their block #0.
+func CheckGenesis(db ethutil.Database, genesis *types.Block) error {
+	stored, _ := db.Get(genesisHashKey)
+	if len(stored) == 0 {
+		stored, _ = db.Get(blockNumKey(0))
+	}
+
+	if len(stored) > 0 && !bytes.Equal(stored, genesis.Hash()) {
+		return fmt.Errorf("genesis mismatch: database has genesis %x, configured genesis is %x", stored, genesis.Hash())
+	}
+
+	return db.Put(genesisHashKey, genesis.Hash())
+}
+
+// Decodes a hex string with or without 0x prefix
+func genesisHex(str string) ([]byte, error) {
+	if len(str) > 1 && str[0:2] == "0x" {
+		str = str[2:]
+	}
+	if len(str)%2 == 1 {
+		str = "0" + str
+	}
+
+	return hex.DecodeString(str)
+}
+
+// Parses a decimal or 0x prefixed hex number
+func genesisBig(str string) (*big.Int, error) {
+	num, ok := new(big.Int).SetString(str, 0)
+	if !ok || num.Sign() < 0 {
+		return nil, fmt.Errorf("invalid number %q", str)
+	}
+
+	return num, nil
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+const testGenesis = `{
+	"difficulty": "0x400",
+	"gasLimit":   "3141592",
+	"timestamp":  "1418000000",
+	"extraData":  "0x6869",
+	"coinbase":   "0x1a26338f0d905e295fccb71fa9ea849ffa12aaf4",
+	"alloc": {
+		"0x51ba59315b3a95761d0863b05ccc7a7f54703d99": {"balance": "1000"},
+		"e4157b34ea9615cfbde6b4fda419828124b70c78": {
+			"balance": "0x10",
+			"code":    "0x6001600055",
+			"storage": {"0x01": "0x2a"}
+		}
+	}
+}`
+
+func TestGenesisSpec(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	ethutil.Config.Db = db
+
+	spec, err := ReadGenesis(strings.NewReader(testGenesis))
+	if err != nil {
+		t.Fatal(err)
+	}
+	genesis, err := spec.Block()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if genesis.Difficulty.Int64() != 1024 || genesis.GasLimit.Int64() != 3141592 || genesis.Time != 1418000000 {
+		t.Errorf("header mismatch: difficulty %v, gas limit %v, time %v", genesis.Difficulty, genesis.GasLimit, genesis.Time)
+	}
+	if genesis.Extra != "hi" {
+		t.Errorf("extra data mismatch: %q", genesis.Extra)
+	}
+	if bytes.Equal(genesis.Hash(), DefaultGenesis().Hash()) {
+		t.Error("custom genesis has the default genesis hash")
+	}
+
+	NewChainManagerWithGenesis(genesis, nil)
+
+	// Reopen the chain, the allocated state must come from the database
+	spec, _ = ReadGenesis(strings.NewReader(testGenesis))
+	genesis, _ = spec.Block()
+	chainMan := NewChainManagerWithGenesis(genesis, nil)
+	if !bytes.Equal(chainMan.CurrentBlock().Hash(), genesis.Hash()) {
+		t.Fatalf("head is %x, expected genesis %x", chainMan.CurrentBlock().Hash(), genesis.Hash())
+	}
+
+	statedb := chainMan.State()
+	if balance := statedb.GetBalance(ethutil.Hex2Bytes("51ba59315b3a95761d0863b05ccc7a7f54703d99")); balance.Int64() != 1000 {
+		t.Errorf("balance mismatch: %v", balance)
+	}
+	contract := ethutil.Hex2Bytes("e4157b34ea9615cfbde6b4fda419828124b70c78")
+	if balance := statedb.GetBalance(contract); balance.Int64() != 16 {
+		t.Errorf("contract balance mismatch: %v", balance)
+	}
+	if code := statedb.GetCode(contract); !bytes.Equal(code, ethutil.Hex2Bytes("6001600055")) {
+		t.Errorf("code mismatch: %x", code)
+	}
+	if value := statedb.GetState(contract, []byte{1}); !bytes.Equal(value, []byte{0x2a}) {
+		t.Errorf("storage mismatch: %x", value)
+	}
+}
+
+func TestGenesisSpecInvalid(t *testing.T) {
+	for _, spec := range []string{
+		`{"difficulty": "lots"}`,
+		`{"gasLimit": "-1"}`,
+		`{"coinbase": "0x1234"}`,
+		`{"alloc": {"0xzz": {"balance": "1"}}}`,
+		`{"alloc": {"51ba59315b3a95761d0863b05ccc7a7f54703d99": {"code": "0xgg"}}}`,
+	} {
+		genesis, err := ReadGenesis(strings.NewReader(spec))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := genesis.Block(); err == nil {
+			t.Errorf("%s: expected error", spec)
+		}
+	}
+
+	if _, err := ReadGenesis(strings.NewReader(`{"alloc": [}`)); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
+
+func TestCheckGenesis(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	ethutil.Config.Db = db
+
+	spec, _ := ReadGenesis(strings.NewReader(testGenesis))
+	genesis, _ := spec.Block()
+
+	if err := CheckGenesis(db, genesis); err != nil {
+		t.Fatal("fresh database:", err)
+	}
+	if err := CheckGenesis(db, genesis); err != nil {
+		t.Fatal("same genesis:", err)
+	}
+	if err := CheckGenesis(db, DefaultGenesis()); err == nil {
+		t.Fatal("expected genesis mismatch")
+	}
+
+	// Databases without a stored genesis hash are checked against block #0
+	legacy, _ := ethdb.NewMemDatabase()
+	ethutil.Config.Db = legacy
+	NewChainManager(nil)
+
+	if err := CheckGenesis(legacy, genesis); err == nil {
+		t.Fatal("expected genesis mismatch for legacy database")
+	}
+	if err := CheckGenesis(legacy, DefaultGenesis()); err != nil {
+		t.Fatal("legacy database:", err)
+	}
+}
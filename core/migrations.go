@@ -0,0 +1,142 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func init() {
+	ethdb.RegisterChainProbe(hasChain)
+	ethdb.RegisterMigration(ethdb.Migration{
+		Version:     2,
+		Description: "store the number and total difficulty of every block in its block info",
+		Migrate:     migrateBlockInfos,
+	})
+	ethdb.RegisterMigration(ethdb.Migration{
+		Version:     3,
+		Description: "index the canonical chain by block number",
+		Migrate:     migrateNumberIndex,
+	})
+	ethdb.RegisterMigration(ethdb.Migration{
+		Version:     4,
+		Description: "index the transactions of the canonical chain by hash",
+		Migrate:     migrateTxLookups,
+	})
+}
+
+// Reports whether the database holds a chain, for ethdb.GetSchemaVersion
+func hasChain(db ethutil.Database) bool {
+	data, _ := db.Get([]byte("LastBlock"))
+
+	return len(data) > 0
+}
+
+// Block infos used to hold a running block count and the total difficulty of
+// the chain before the block was inserted. Rewrites them with the block's own
+// number and total difficulty, parents first, and stores the corrected total
+// difficulty of the head.
+func migrateBlockInfos(db ethutil.Database, batch ethutil.Batch) (int, error) {
+	var blocks types.Blocks
+
+	it := db.NewIterator(nil)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != 36 || !bytes.HasSuffix(key, []byte("Info")) {
+			continue
+		}
+
+		if data, _ := db.Get(key[:32]); len(data) > 0 {
+			blocks = append(blocks, types.NewBlockFromBytes(data))
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return 0, err
+	}
+
+	types.BlockBy(types.Number).Sort(blocks)
+
+	tds := make(map[string]*big.Int)
+	for _, block := range blocks {
+		td := new(big.Int)
+		if parentTd, ok := tds[string(block.PrevHash)]; ok {
+			td.Add(td, parentTd)
+		}
+		for _, uncle := range block.Uncles {
+			td.Add(td, uncle.Difficulty)
+		}
+		if block.Number.Sign() > 0 {
+			td.Add(td, block.Difficulty)
+		}
+		tds[string(block.Hash())] = td
+
+		bi := types.BlockInfo{Number: block.Number.Uint64(), Hash: block.Hash(), Parent: block.PrevHash, TD: td}
+		if err := batch.Put(append(block.Hash(), []byte("Info")...), bi.RlpEncode()); err != nil {
+			return 0, err
+		}
+	}
+
+	if data, _ := db.Get([]byte("LastBlock")); len(data) > 0 {
+		if td, ok := tds[string(types.NewBlockFromBytes(data).Hash())]; ok {
+			if err := batch.Put([]byte("LTD"), td.Bytes()); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(blocks), nil
+}
+
+// Writes the canonical number index entries of all blocks from the head down
+// to the genesis block.
+func migrateNumberIndex(db ethutil.Database, batch ethutil.Batch) (int, error) {
+	data, _ := db.Get([]byte("LastBlock"))
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	var count int
+	for block := types.NewBlockFromBytes(data); ; count++ {
+		if err := batch.Put(blockNumKey(block.Number.Uint64()), block.Hash()); err != nil {
+			return count, err
+		}
+
+		if block.Number.Sign() == 0 {
+			return count + 1, nil
+		}
+
+		parent, _ := db.Get(block.PrevHash)
+		if len(parent) == 0 {
+			return count, fmt.Errorf("block %x missing from database", block.PrevHash)
+		}
+		block = types.NewBlockFromBytes(parent)
+	}
+}
+
+// Writes the lookup entries of the transactions of all blocks of the
+// canonical chain. Relies on the number index written by version 3.
+func migrateTxLookups(db ethutil.Database, batch ethutil.Batch) (int, error) {
+	var count int
+	for num := uint64(0); ; num++ {
+		hash, _ := db.Get(blockNumKey(num))
+		if len(hash) == 0 {
+			return count, nil
+		}
+
+		data, _ := db.Get(hash)
+		if len(data) == 0 {
+			return count, fmt.Errorf("block %x missing from database", hash)
+		}
+		block := types.NewBlockFromBytes(data)
+
+		if err := writeTxLookups(batch, block); err != nil {
+			return count, err
+		}
+		count += len(block.Transactions())
+	}
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:20]); err != nil {
+		t.Fatal(err)
+	}
+	td := chainMan.Td()
+
+	// Block infos as written before schema version 2, no number index
+	expected := make([]types.BlockInfo, 20)
+	for i, block := range chain[:20] {
+		expected[i] = chainMan.BlockInfo(block)
+
+		bi := types.BlockInfo{Number: uint64(i + 1), Hash: block.Hash(), Parent: block.PrevHash, TD: ethutil.Big0}
+		db.Put(append(block.Hash(), []byte("Info")...), bi.RlpEncode())
+		db.Delete(blockNumKey(uint64(i)))
+	}
+	db.Put([]byte("LTD"), []byte{1})
+
+	results, err := ethdb.UpgradeSchema(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uint64(len(results)) != ethdb.SchemaVersion() || results[1].Changed != 20 || results[2].Changed != 20 {
+		t.Fatalf("unexpected migration results %v", results)
+	}
+
+	for i, block := range chain[:20] {
+		bi := chainMan.BlockInfo(block)
+		if bi.Number != expected[i].Number || bi.TD.Cmp(expected[i].TD) != 0 {
+			t.Errorf("block #%d: expected (#%d, td %v), got (#%d, td %v)", i, expected[i].Number, expected[i].TD, bi.Number, bi.TD)
+		}
+		if b := chainMan.GetBlockByNumber(uint64(i)); b == nil || !bytes.Equal(b.Hash(), block.Hash()) {
+			t.Errorf("block #%d missing from the number index", i)
+		}
+	}
+	if data, _ := db.Get([]byte("LTD")); ethutil.BigD(data).Cmp(td) != 0 {
+		t.Errorf("expected total difficulty %v, got %v", td, ethutil.BigD(data))
+	}
+}
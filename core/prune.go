@@ -0,0 +1,73 @@
+package core
+
+import (
+	"bytes"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+)
+
+// Number of deletes written per batch while pruning
+const pruneBatchSize = 1000
+
+// Deletes the state trie nodes and code which are not needed by the state of
+// the last keep blocks of the canonical chain and returns the number of
+// deleted entries. Older blocks are kept but their state is gone afterwards.
+//
+// Pruning works by mark and sweep. The states which are kept are marked
+// first, then every content addressed entry (one whose key is the hash of its
+// value, which is how trie nodes and code are stored) that isn't marked is
+// deleted. Blocks and all other entries are keyed differently and never
+// touched.
+//
+// The chain must not be processing blocks while pruning.
+func (self *ChainManager) PruneState(keep uint64) (int, error) {
+	db := ethutil.Config.Db
+
+	marked := make(map[string]bool)
+	block := self.CurrentBlock()
+	for i := uint64(0); i < keep && block != nil; i++ {
+		if err := state.MarkNodes(db, block.State().Root(), marked); err != nil {
+			return 0, err
+		}
+
+		block = self.GetBlock(block.PrevHash)
+	}
+	chainlogger.Infof("marked %d state entries\n", len(marked))
+
+	it := db.NewIterator(nil)
+	defer it.Release()
+
+	var (
+		deleted int
+		batch   = db.NewBatch()
+	)
+	for it.Next() {
+		key := it.Key()
+		if len(key) != 32 || marked[string(key)] || !bytes.Equal(crypto.Sha3(it.Value()), key) {
+			continue
+		}
+
+		if err := batch.Delete(key); err != nil {
+			return deleted, err
+		}
+		deleted++
+
+		if deleted%pruneBatchSize == 0 {
+			if err := batch.Write(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return deleted, err
+	}
+
+	if err := batch.Write(); err != nil {
+		return deleted, err
+	}
+	chainlogger.Infof("pruned %d state entries\n", deleted)
+
+	return deleted, nil
+}
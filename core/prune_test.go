@@ -0,0 +1,55 @@
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+)
+
+func TestPruneState(t *testing.T) {
+	chain := loadChain("chain1", t)
+
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[:60]); err != nil {
+		t.Fatal(err)
+	}
+
+	before := db.Len()
+	deleted, err := chainMan.PruneState(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted == 0 || db.Len() != before-deleted {
+		t.Fatalf("expected entries to be deleted, deleted %d of %d (%d left)", deleted, before, db.Len())
+	}
+
+	// The kept states must be complete, older ones are gone
+	for i := 50; i < 60; i++ {
+		if err := state.MarkNodes(db, chain[i].State().Root(), make(map[string]bool)); err != nil {
+			t.Errorf("block #%d: %v", i, err)
+		}
+	}
+	if err := state.MarkNodes(db, chain[40].State().Root(), make(map[string]bool)); err == nil {
+		t.Error("expected state of block #40 to be pruned")
+	}
+
+	// Blocks are kept
+	if chainMan.GetBlock(chain[1].Hash()) == nil {
+		t.Error("expected block #1 to be kept")
+	}
+
+	// Processing continues on top of the pruned state
+	chainMan = newChainManagerWithDb(db)
+	if err := chainMan.InsertChain(chain[60:80]); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(chainMan.CurrentBlock().Hash(), chain[79].Hash()) {
+		t.Errorf("expected head #%v, got #%v", chain[79].Number, chainMan.CurrentBlock().Number)
+	}
+}
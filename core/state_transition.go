@@ -70,6 +70,21 @@ func MessageGasValue(msg Message) *big.Int {
 	return new(big.Int).Mul(msg.Gas(), msg.GasPrice())
 }
 
+// Returns the gas a message with the given data uses before any code runs: the GasTx of every
+// transaction, plus GasData for every non zero byte of data and 1 for every zero byte.
+func IntrinsicGas(data []byte) *big.Int {
+	gas := new(big.Int).Set(vm.GasTx)
+	for _, byt := range data {
+		if byt != 0 {
+			gas.Add(gas, vm.GasData)
+		} else {
+			gas.Add(gas, ethutil.Big1) // This is 1/5. If GasData changes this fails
+		}
+	}
+
+	return gas
+}
+
 // creates and returns a todo StateTransition object.
 // The fields _gas_ and initialGas are set to 0
 // The fields rec, sen and Env are set to nil
@@ -218,21 +233,8 @@ func (self *StateTransition) TransitionState() (ret []byte, err error) {
 	// Increment the nonce for the next transaction
 	sender.Nonce += 1
 
-	// Transaction gas
-	if err = self.UseGas(vm.GasTx); err != nil {
-		return
-	}
-
-	// Pay data gas
-	var dgas int64
-	for _, byt := range self.data {
-		if byt != 0 {
-			dgas += vm.GasData.Int64()
-		} else {
-			dgas += 1 // This is 1/5. If GasData changes this fails
-		}
-	}
-	if err = self.UseGas(big.NewInt(dgas)); err != nil {
+	// Transaction and data gas
+	if err = self.UseGas(IntrinsicGas(self.data)); err != nil {
 		return
 	}
 
@@ -2,10 +2,13 @@ package core
 
 import (
 	"bytes"
+	"container/heap"
 	"container/list"
 	"fmt"
 	"math/big"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/event"
@@ -16,6 +19,9 @@ import (
 
 var txplogger = logger.NewLogger("TXP")
 
+// Transactions with more data are rejected by the pool
+const maxTxDataSize = 32 * 1024
+
 // Used to initialize the queueChan field of a TxPool
 // (which is used as a queue channel to reading and writing transactions)
 const txPoolQueueSize = 50
@@ -28,9 +34,21 @@ const (
 	minGasPrice = 1000000
 )
 
-// Although defined, this variable is never used in this go-ethereum version.
+// The default minimum gas price of a TxPool (see TxPool.MinGasPrice).
 var MinGasPrice = big.NewInt(10000000000000)
 
+// Defaults of the remaining TxPool settings
+const (
+	// Percentage by which a transaction must outbid the one it replaces
+	DefaultPriceBump = 10
+	// Maximum number of transactions in the pool
+	DefaultGlobalSlots = 4096
+	// Maximum number of transactions of a single sender in the pool
+	DefaultAccountSlots = 64
+	// Interval at which the journal of local transactions is rewritten
+	DefaultRejournal = time.Hour
+)
+
 // The only use of a TxMsgTy type is as a field of a TxMsg type.
 // Although it's not clear how this type is supposed to be used, since
 // there are no other references to it in the whole codebase, we could
@@ -89,38 +107,83 @@ type TxProcessor interface {
 // independently read without needing access to the actual pool. If the
 // pool is being drained or synced for whatever reason, the transactions
 // will simply queue up and be handled when the mutex is freed.
+//
+// Transactions are kept per sender account, sorted by nonce. Transactions
+// which can be executed on top of the current state, i.e. whose nonces
+// follow the account's nonce without a gap, are pending; the others are
+// queued until the gap is filled. The pool is brought up to date with every
+// new head of the chain (see todo RemoveInvalid).
+//
 // mutex: a mutex for accessing the Tx pool.
 // queueChan: Queueing channel for reading and writing incoming transactions to
 // quit: Quiting channel (quitting is equivalent to emptying the TxPool)
-// pool: The actual pool, aka the list of transactions.
+// all: all transactions of the pool, by hash.
+// pending: the executable transactions, by sender.
+// queue: the transactions waiting for a nonce gap to be filled, by sender.
+// MinGasPrice: transactions with a lower gas price are rejected.
+// PriceBump: the percentage by which the gas price of a transaction must exceed the gas price
+// of the pool's transaction with the same sender and nonce to replace it.
+// GlobalSlots: the maximum number of transactions in the pool. Once exceeded the transaction
+// with the lowest gas price is evicted.
+// AccountSlots: the maximum number of transactions of a single sender in the pool. Once exceeded
+// the sender's transaction with the lowest gas price is evicted.
+// Rejournal: the interval at which the journal of local transactions is rewritten.
+// locals: the hashes of the transactions submitted locally (See todo AddLocal), which are never evicted.
+// added: the time each transaction of the pool was added at, by hash.
+// journal: the file local transactions are kept in across restarts, nil if none was opened
+// (See todo OpenJournal).
 // SecondaryProcessor: This field is actually never used as the todo TxProcessor interface is not implemented.
 // subscribers: Although defined, this channel is never used.
 // broadcaster: used to broadcast messages to all connected peers.
 // chainManager: the chain to which the TxPool object is attached to.
 // eventMux: used to dispatch events to subscribers.
+// events: subscription to the chain events the pool reacts to.
 type TxPool struct {
 	mutex              sync.Mutex
 	queueChan          chan *types.Transaction
 	quit               chan bool
-	pool               *list.List
+	all                map[string]*types.Transaction
+	pending            map[string]*txList
+	queue              map[string]*txList
+	MinGasPrice        *big.Int
+	PriceBump          uint64
+	GlobalSlots        int
+	AccountSlots       int
+	Rejournal          time.Duration
+	locals             map[string]bool
+	added              map[string]time.Time
+	journal            *txJournal
 	SecondaryProcessor TxProcessor
 	subscribers        []chan TxMsg
 	broadcaster        types.Broadcaster
 	chainManager       *ChainManager
 	eventMux           *event.TypeMux
+	events             event.Subscription
 }
 
 // todo NewTxPool creates a new todo TxPool object and sets it's fields.
-// TxPool.pool will be empty.
+// TxPool.all, TxPool.pending, TxPool.queue, TxPool.locals and TxPool.added will be empty.
 // TxPool.queueChain wil be set to a Transaction channel with a txPoolQueueSize size.
 // TxPool.quit will be set to a boolean channel.
+// TxPool.MinGasPrice, TxPool.PriceBump, TxPool.GlobalSlots, TxPool.AccountSlots and TxPool.Rejournal
+// will be set to their defaults (MinGasPrice, DefaultPriceBump, DefaultGlobalSlots, DefaultAccountSlots
+// and DefaultRejournal).
 // TxPool.chainManager will be assigned the param _chainManager_
 // TxPool.eventMux will be assigned the param _eventMux_
 // TxPool.broadcaster will be assigned the param _broadcaster_
 // All other fields of the todo TxPool object that gets created are not set by NewTxPool.
 func NewTxPool(chainManager *ChainManager, broadcaster types.Broadcaster, eventMux *event.TypeMux) *TxPool {
 	return &TxPool{
-		pool:         list.New(),
+		all:          make(map[string]*types.Transaction),
+		pending:      make(map[string]*txList),
+		queue:        make(map[string]*txList),
+		locals:       make(map[string]bool),
+		added:        make(map[string]time.Time),
+		MinGasPrice:  MinGasPrice,
+		PriceBump:    DefaultPriceBump,
+		GlobalSlots:  DefaultGlobalSlots,
+		AccountSlots: DefaultAccountSlots,
+		Rejournal:    DefaultRejournal,
 		queueChan:    make(chan *types.Transaction, txPoolQueueSize),
 		quit:         make(chan bool),
 		chainManager: chainManager,
@@ -129,35 +192,233 @@ func NewTxPool(chainManager *ChainManager, broadcaster types.Broadcaster, eventM
 	}
 }
 
-// todo addTransaction is an inner function used to add the
-// todo Transaction _tx_ to the end of the TxPool. Also, a message
-// is broadcasted to all peers which contains the rlp-encodable fields
-// _tx_ (See todo RlpData)
-// todo locked.
-func (pool *TxPool) addTransaction(tx *types.Transaction) {
-	pool.mutex.Lock()
-	defer pool.mutex.Unlock()
+// todo addTransaction is an inner function used to add the todo Transaction
+// _tx_ to the queue of its sender, from where it is promoted to the pending
+// transactions once it is executable (See todo promote).
+// A transaction of the same sender with the same nonce is replaced if _tx_'s gas
+// price is at least PriceBump percent higher. If the sender or the pool hold too
+// many transactions afterwards, the transaction with the lowest gas price is evicted
+// (See todo evict). Transactions added with _local_ set are never evicted.
+// Returns an error if _tx_ is already known, invalid (See todo ValidateTransaction),
+// its gas price is below MinGasPrice, it doesn't outbid the transaction it would
+// replace or it was evicted itself.
+// todo not locked.
+func (pool *TxPool) addTransaction(tx *types.Transaction, local bool) error {
+	hash := tx.Hash()
+	if pool.all[string(hash)] != nil {
+		return fmt.Errorf("Known transaction (%x)", hash[0:4])
+	}
 
-	pool.pool.PushBack(tx)
+	if err := pool.ValidateTransaction(tx); err != nil {
+		return err
+	}
 
-	// Broadcast the transaction to the rest of the peers
-	pool.broadcaster.Broadcast(wire.MsgTxTy, []interface{}{tx.RlpData()})
+	if tx.GasPrice().Cmp(pool.MinGasPrice) < 0 {
+		return fmt.Errorf("Gas price %v below minimum %v", tx.GasPrice(), pool.MinGasPrice)
+	}
+
+	from := tx.From()
+	nonce := pool.chainManager.State().GetNonce(from)
+
+	old := pool.get(string(from), tx.Nonce())
+	if old != nil {
+		threshold := new(big.Int).Mul(old.GasPrice(), big.NewInt(int64(100+pool.PriceBump)))
+		threshold.Div(threshold, big.NewInt(100))
+		if tx.GasPrice().Cmp(threshold) < 0 {
+			return fmt.Errorf("Replacement transaction underpriced, gas price %v below %v", tx.GasPrice(), threshold)
+		}
+
+		pool.removeTransaction(old.Hash())
+	}
+
+	pool.all[string(hash)] = tx
+	pool.added[string(hash)] = time.Now()
+	if local {
+		pool.locals[string(hash)] = true
+	}
+	pool.enqueue(tx)
+	pool.promote(string(from), nonce)
+
+	if old != nil {
+		txplogger.Debugf("replaced %x with %x (gas price %v => %v)\n", old.Hash()[:4], hash[:4], old.GasPrice(), tx.GasPrice())
+		return nil
+	}
+
+	if pool.count(string(from)) > pool.AccountSlots {
+		if pool.evict(string(from), tx) == tx {
+			return fmt.Errorf("Transaction underpriced, %x has %d transactions in the pool", from[:4], pool.AccountSlots)
+		}
+	}
+	if len(pool.all) > pool.GlobalSlots {
+		if pool.evict("", tx) == tx {
+			return fmt.Errorf("Transaction pool full, gas price %v too low", tx.GasPrice())
+		}
+	}
+
+	return nil
+}
+
+// todo count is an inner function which returns the number of pending and
+// queued transactions of sender _from_.
+func (pool *TxPool) count(from string) int {
+	var n int
+	if list := pool.pending[from]; list != nil {
+		n += list.Len()
+	}
+	if list := pool.queue[from]; list != nil {
+		n += list.Len()
+	}
+
+	return n
+}
+
+// todo evict is an inner function which removes the transaction with the
+// lowest gas price of sender _from_, or of the whole pool if _from_ is empty,
+// and returns it. Of equally priced transactions the incoming transaction
+// _tx_ is evicted first, then the one with the highest nonce, which has the
+// most transactions depending on it. Local transactions are never evicted,
+// nil is returned if there is no other transaction to evict.
+func (pool *TxPool) evict(from string, tx *types.Transaction) *types.Transaction {
+	var victim *types.Transaction
+	cheaper := func(t *types.Transaction) bool {
+		if victim == nil {
+			return true
+		}
+		if c := t.GasPrice().Cmp(victim.GasPrice()); c != 0 {
+			return c < 0
+		}
+		if victim == tx || t == tx {
+			return t == tx
+		}
+		return t.Nonce() > victim.Nonce()
+	}
+
+	for _, t := range pool.all {
+		if pool.locals[string(t.Hash())] {
+			continue
+		}
+		if (from == "" || string(t.From()) == from) && cheaper(t) {
+			victim = t
+		}
+	}
+
+	if victim != nil {
+		pool.removeTransaction(victim.Hash())
+		txplogger.Debugf("evicted %x (gas price %v)\n", victim.Hash()[:4], victim.GasPrice())
+	}
+
+	return victim
+}
+
+// todo get is an inner function which returns the pending or queued
+// transaction of sender _from_ with nonce _nonce_, or nil.
+func (pool *TxPool) get(from string, nonce uint64) *types.Transaction {
+	if list := pool.pending[from]; list != nil && list.Get(nonce) != nil {
+		return list.Get(nonce)
+	}
+	if list := pool.queue[from]; list != nil {
+		return list.Get(nonce)
+	}
+
+	return nil
+}
+
+// todo enqueue is an inner function which adds _tx_ to the queued
+// transactions of its sender.
+func (pool *TxPool) enqueue(tx *types.Transaction) {
+	from := string(tx.From())
+	if pool.queue[from] == nil {
+		pool.queue[from] = newTxList()
+	}
+	pool.queue[from].Put(tx)
+}
+
+// todo promote is an inner function which moves the queued transactions of
+// sender _from_ which follow its pending transactions without a gap to the
+// pending transactions. _nonce_ is the nonce of the sender's account, which
+// the first pending transaction must have.
+func (pool *TxPool) promote(from string, nonce uint64) {
+	queued := pool.queue[from]
+	if queued == nil {
+		return
+	}
+
+	pending := pool.pending[from]
+	if pending == nil {
+		pending = newTxList()
+	}
+
+	for _, tx := range queued.Ready(nonce + uint64(pending.Len())) {
+		pending.Put(tx)
+	}
+
+	if pending.Len() > 0 {
+		pool.pending[from] = pending
+	}
+	if queued.Len() == 0 {
+		delete(pool.queue, from)
+	}
+}
+
+// todo removeTransaction is an inner function which removes the transaction
+// with hash _hash_ from the pool. The pending transactions of the sender with
+// a higher nonce are no longer executable and are queued again.
+func (pool *TxPool) removeTransaction(hash []byte) {
+	tx := pool.all[string(hash)]
+	if tx == nil {
+		return
+	}
+	pool.forget(string(hash))
+
+	from := string(tx.From())
+	if pending := pool.pending[from]; pending != nil && pending.Get(tx.Nonce()) == tx {
+		pending.Remove(tx.Nonce())
+		for _, tx := range pending.Cap(tx.Nonce()) {
+			pool.enqueue(tx)
+		}
+		if pending.Len() == 0 {
+			delete(pool.pending, from)
+		}
+
+		return
+	}
+
+	if queued := pool.queue[from]; queued != nil && queued.Get(tx.Nonce()) == tx {
+		queued.Remove(tx.Nonce())
+		if queued.Len() == 0 {
+			delete(pool.queue, from)
+		}
+	}
 }
 
-// todo ValidateTransaction validates the _tx_ todo Transaction.
+// todo forget is an inner function which drops everything the pool knows about the
+// transaction with hash _hash_ except for its place in the pending or queued
+// transactions of its sender.
+func (pool *TxPool) forget(hash string) {
+	delete(pool.all, hash)
+	delete(pool.locals, hash)
+	delete(pool.added, hash)
+}
+
+// todo ValidateTransaction validates the _tx_ todo Transaction against the state of the
+// current head of the chain.
 // Returns either an error if _tx_ can not be validated or nil.
 // These are the cases where _tx_ is not validated:
-// 1. For some reason, the currentBlock field of the chainManager field
+// 1. For some reason, the current block of the chainManager field
 // of the caller is nil. (aka the chain is empty)
-// 2. The recipient field (_to_) of _tx_ is is either nil or != 20 bytes.
-// This means that the validation of contract creation transactions,
-// for which  the recipient (_to_) is set to nil will always return an error.
+// 2. The recipient field (_to_) of _tx_ is neither empty (contract creation) nor 20 bytes.
 // 3. The _v_ field of _tx_ is neither 28 nor 27. (See todo Transaction object)
-// 4. The sender account of _tx_ does not have enough Ether to send to the recipient of _tx_
+// 4. The data of _tx_ is larger than maxTxDataSize (a todo OversizedDataErr).
+// 5. The gas of _tx_ exceeds the gas limit of the current block (a todo GasLimitErr).
+// 6. The gas of _tx_ doesn't cover its intrinsic gas (a todo IntrinsicGasErr, see todo IntrinsicGas).
+// 7. The nonce of _tx_ is lower than the nonce of the sender's account (a todo NonceErr).
+// Higher nonces are valid, such transactions are queued by the pool.
+// 8. The sender account of _tx_ can't pay for the value of _tx_ plus its gas times its gas
+// price (a todo InsufficientFundsErr).
 func (pool *TxPool) ValidateTransaction(tx *types.Transaction) error {
 	// Get the last block so we can retrieve the sender and receiver from
 	// the merkle trie
-	block := pool.chainManager.CurrentBlock
+	block := pool.chainManager.CurrentBlock()
 	// Something has gone horribly wrong if this happens
 	if block == nil {
 		return fmt.Errorf("No last block on the block chain")
@@ -172,50 +433,83 @@ func (pool *TxPool) ValidateTransaction(tx *types.Transaction) error {
 		return fmt.Errorf("tx.v != (28 || 27)")
 	}
 
+	if len(tx.Data()) > maxTxDataSize {
+		return OversizedDataError(len(tx.Data()), maxTxDataSize)
+	}
+
+	if tx.Gas().Cmp(block.GasLimit) > 0 {
+		return GasLimitError(tx.Gas(), block.GasLimit)
+	}
+
+	if required := IntrinsicGas(tx.Data()); tx.Gas().Cmp(required) < 0 {
+		return IntrinsicGasError(tx.Gas(), required)
+	}
+
 	// Get the sender
 	sender := pool.chainManager.State().GetAccount(tx.Sender())
 
-	totAmount := new(big.Int).Set(tx.Value())
-	// Make sure there's enough in the sender's account. Having insufficient
-	// funds won't invalidate this transaction but simple ignores it.
-	if sender.Balance().Cmp(totAmount) < 0 {
-		return fmt.Errorf("Insufficient amount in sender's (%x) account", tx.From())
+	// Transactions with a lower nonce were replaced or included already, preventing replay attacks
+	if tx.Nonce() < sender.Nonce {
+		return NonceError(tx.Nonce(), sender.Nonce)
 	}
 
-	// Increment the nonce making each tx valid only once to prevent replay
-	// attacks
+	// Make sure there's enough in the sender's account to pay for the value and the gas
+	cost := new(big.Int).Add(tx.Value(), MessageGasValue(tx))
+	if sender.Balance().Cmp(cost) < 0 {
+		return InsufficientFundsError(sender.Balance(), cost)
+	}
 
 	return nil
 }
 
 // todo Add is the function to be called for adding a todo Transaction to the todo TxPool caller.
 // Returns either an error on not successfully adding _tx_ or nil for success.
-// If _tx_ was added, a message is posted to the subscribed peers, containing the
-// _tx_ from, to, value and hash fields.
+// If _tx_ was added, it is broadcasted to all peers and a message is posted to the
+// subscribers, containing the _tx_ from, to, value and hash fields.
 // An error is returned in any of these cases:
 // 1. _tx_'s hash already exists in the todo TxPool caller, aka the transaction
 // to be added is already part of the caller.
-// 2. _tx_ validation returned an error when calling todo ValidateTransaction.
-// If no errors are produced from steps 1. and 2. todo Add makes a call to the inner
-// function todo addTransaction to add _tx_ to the current todo TxPool.
+// 2. _tx_ validation returned an error when calling todo ValidateTransaction, which
+// returns distinct error types for the checks consumers may want to tell apart.
+// 3. _tx_'s gas price is below the MinGasPrice of the caller.
+// 4. the pool holds another transaction of the same sender with the same nonce and _tx_'s
+// gas price doesn't exceed its gas price by PriceBump percent.
+// 5. the sender or the pool hold too many transactions and _tx_ has the lowest gas price.
+// See todo addTransaction.
 func (self *TxPool) Add(tx *types.Transaction) error {
-	hash := tx.Hash()
-	foundTx := FindTx(self.pool, func(tx *types.Transaction, e *list.Element) bool {
-		return bytes.Compare(tx.Hash(), hash) == 0
-	})
+	return self.add(tx, false)
+}
 
-	if foundTx != nil {
-		return fmt.Errorf("Known transaction (%x)", hash[0:4])
+// todo AddLocal adds a todo Transaction submitted through this node, like todo Add.
+// Unlike other transactions, _tx_ is never evicted to make room for better paying
+// ones and, if a journal was opened (See todo OpenJournal), it is written to the
+// journal so it is added again after a restart of the node.
+func (self *TxPool) AddLocal(tx *types.Transaction) error {
+	return self.add(tx, true)
+}
+
+// todo add is an inner function which adds _tx_ for todo Add and todo AddLocal,
+// broadcasts it and notifies the subscribers.
+func (self *TxPool) add(tx *types.Transaction, local bool) error {
+	self.mutex.Lock()
+	err := self.addTransaction(tx, local)
+	if err == nil && local && self.journal != nil {
+		if err := self.journal.insert(tx); err != nil {
+			txplogger.Warnf("failed to journal local transaction %x: %v\n", tx.Hash()[:4], err)
+		}
 	}
+	self.mutex.Unlock()
 
-	err := self.ValidateTransaction(tx)
 	if err != nil {
 		return err
 	}
 
-	self.addTransaction(tx)
+	// Broadcast the transaction to the rest of the peers
+	if self.broadcaster != nil {
+		self.broadcaster.Broadcast(wire.MsgTxTy, []interface{}{tx.RlpData()})
+	}
 
-	txplogger.Debugf("(t) %x => %x (%v) %x\n", tx.From()[:4], tx.To()[:4], tx.Value, tx.Hash())
+	txplogger.Debugf("(t) %x => %x (%v) %x\n", tx.From()[:4], tx.To(), tx.Value(), tx.Hash())
 
 	// Notify the subscribers
 	go self.eventMux.Post(TxPreEvent{tx})
@@ -223,83 +517,323 @@ func (self *TxPool) Add(tx *types.Transaction) error {
 	return nil
 }
 
-// todo Size returns the number of Transactions of the caller.
+// todo Size returns the number of Transactions of the caller, pending and queued.
 func (self *TxPool) Size() int {
-	return self.pool.Len()
+	self.mutex.Lock()
+	defer self.mutex.Unlock()
+
+	return len(self.all)
 }
 
-// todo CurrentTransactions returns the transactions of the todo TxPool caller as a slice.
+// todo CurrentTransactions returns the transactions of the todo TxPool caller as a slice:
+// the pending transactions followed by the queued ones, each sender's in nonce order.
 func (pool *TxPool) CurrentTransactions() []*types.Transaction {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	txList := make([]*types.Transaction, pool.pool.Len())
-	i := 0
-	for e := pool.pool.Front(); e != nil; e = e.Next() {
-		tx := e.Value.(*types.Transaction)
+	txs := make([]*types.Transaction, 0, len(pool.all))
+	for _, pending := range pool.pending {
+		txs = append(txs, pending.Flatten()...)
+	}
+	for _, queued := range pool.queue {
+		txs = append(txs, queued.Flatten()...)
+	}
+
+	return txs
+}
+
+// todo PendingTransactions returns the executable transactions of the todo TxPool caller,
+// which is what a miner should include in a block. The transactions are ordered by gas
+// price, highest first, while the transactions of each sender stay in nonce order, so
+// every transaction follows the previous one of its sender.
+func (pool *TxPool) PendingTransactions() types.Transactions {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var (
+		heads  = make(txPriceHeap, 0, len(pool.pending))
+		rest   = make(map[string]types.Transactions)
+		sorted = make(types.Transactions, 0, len(pool.all))
+	)
+	for from, list := range pool.pending {
+		txs := list.Flatten()
+		heads = append(heads, txs[0])
+		rest[from] = txs[1:]
+	}
+	heap.Init(&heads)
+
+	for heads.Len() > 0 {
+		tx := heap.Pop(&heads).(*types.Transaction)
+		sorted = append(sorted, tx)
+
+		from := string(tx.From())
+		if txs := rest[from]; len(txs) > 0 {
+			heap.Push(&heads, txs[0])
+			rest[from] = txs[1:]
+		}
+	}
+
+	return sorted
+}
+
+// todo TxStatus describes a transaction of the pool for todo Inspect.
+// Added is the time the transaction was added to the pool at, Local tells
+// whether it was submitted through this node (See todo AddLocal).
+type TxStatus struct {
+	Tx    *types.Transaction
+	Added time.Time
+	Local bool
+}
+
+// todo TxPoolAccount holds the transactions of a single sender in the pool
+// for todo Inspect, each list in nonce order. Nonce is the nonce of the
+// sender's account, which the first pending transaction has. Queued
+// transactions wait for the transactions with the nonces missing in between.
+type TxPoolAccount struct {
+	Address []byte
+	Nonce   uint64
+	Pending []*TxStatus
+	Queued  []*TxStatus
+}
+
+type txPoolAccounts []*TxPoolAccount
+
+func (s txPoolAccounts) Len() int           { return len(s) }
+func (s txPoolAccounts) Less(i, j int) bool { return bytes.Compare(s[i].Address, s[j].Address) < 0 }
+func (s txPoolAccounts) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// todo Inspect returns the transactions of the todo TxPool caller grouped by
+// sender, ordered by address, which tells why transactions aren't mined: they
+// are queued behind a nonce gap, or pending but paying too little gas.
+func (pool *TxPool) Inspect() []*TxPoolAccount {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var (
+		state    = pool.chainManager.State()
+		accounts = make(map[string]*TxPoolAccount)
+	)
+	account := func(from string) *TxPoolAccount {
+		if accounts[from] == nil {
+			accounts[from] = &TxPoolAccount{Address: []byte(from), Nonce: state.GetNonce([]byte(from))}
+		}
+		return accounts[from]
+	}
+	status := func(list *txList) []*TxStatus {
+		var txs []*TxStatus
+		for _, tx := range list.Flatten() {
+			hash := string(tx.Hash())
+			txs = append(txs, &TxStatus{Tx: tx, Added: pool.added[hash], Local: pool.locals[hash]})
+		}
+		return txs
+	}
 
-		txList[i] = tx
+	for from, list := range pool.pending {
+		account(from).Pending = status(list)
+	}
+	for from, list := range pool.queue {
+		account(from).Queued = status(list)
+	}
 
-		i++
+	sorted := make(txPoolAccounts, 0, len(accounts))
+	for _, acc := range accounts {
+		sorted = append(sorted, acc)
 	}
+	sort.Sort(sorted)
 
-	return txList
+	return sorted
 }
 
-// todo RemoveInvalid removed all transactions from the caller for which either:
-// 1. the transaction returns an error when validated through the todo ValidateTransaction function, or
-// 2. the transaction sender's nonce field is >= to the transaction's nonce field.
+// todo RemoveInvalid brings the caller up to date with _state_, the state of the new head of
+// the chain. Transactions are removed for which either:
+// 1. the transaction's nonce is below the nonce of its sender's account in _state_, which
+// means it was included in the chain (or was replaced by another transaction), or
+// 2. the transaction returns an error when validated through the todo ValidateTransaction function.
+// The remaining transactions of each sender which are executable on top of _state_ are pending,
+// the others are queued.
 func (pool *TxPool) RemoveInvalid(state *state.StateDB) {
 	pool.mutex.Lock()
 	defer pool.mutex.Unlock()
 
-	for e := pool.pool.Front(); e != nil; e = e.Next() {
-		tx := e.Value.(*types.Transaction)
-		sender := state.GetAccount(tx.Sender())
-		err := pool.ValidateTransaction(tx)
-		if err != nil || sender.Nonce >= tx.Nonce() {
-			pool.pool.Remove(e)
+	// Queue everything, the executable transactions are promoted again below
+	for from, pending := range pool.pending {
+		for _, tx := range pending.Flatten() {
+			pool.enqueue(tx)
 		}
+		delete(pool.pending, from)
+	}
+
+	for from, queued := range pool.queue {
+		nonce := state.GetNonce([]byte(from))
+		for _, tx := range queued.Forward(nonce) {
+			pool.forget(string(tx.Hash()))
+		}
+		for _, tx := range queued.Flatten() {
+			if pool.ValidateTransaction(tx) != nil {
+				queued.Remove(tx.Nonce())
+				pool.forget(string(tx.Hash()))
+			}
+		}
+
+		pool.promote(from, nonce)
 	}
 }
 
 // todo RemoveSet takes as an argument a set of transactions _txs_ and
 // removes from the caller's transactions set those that match the ones from _txs_.
-// Looping over the transactions of the caller happens through the todo EachTx function.
+// Pending transactions of the same senders with higher nonces are queued again.
 func (self *TxPool) RemoveSet(txs types.Transactions) {
 	self.mutex.Lock()
 	defer self.mutex.Unlock()
 
 	for _, tx := range txs {
-		EachTx(self.pool, func(t *types.Transaction, element *list.Element) bool {
-			if t == tx {
-				self.pool.Remove(element)
-				return true // To stop the loop
-			}
-			return false
-		})
+		self.removeTransaction(tx.Hash())
 	}
 }
 
-// todo Flush resets the caller's transactions list to an empty list.
+// todo Flush empties the caller and returns the transactions it held.
 func (pool *TxPool) Flush() []*types.Transaction {
-	txList := pool.CurrentTransactions()
+	txs := pool.CurrentTransactions()
 
-	// Recreate a new list all together
-	// XXX Is this the fastest way?
-	pool.pool = list.New()
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.all = make(map[string]*types.Transaction)
+	pool.pending = make(map[string]*txList)
+	pool.queue = make(map[string]*txList)
+	pool.locals = make(map[string]bool)
+	pool.added = make(map[string]time.Time)
 
-	return txList
+	return txs
 }
 
-// Although defined, this function does not contain any executable code in this go-ethereum version.
+// todo Start subscribes the pool to chain reorganisations and new heads.
+// Transactions of blocks which are dropped from the canonical chain are put
+// back into the pool (see todo Readd), and the pool is brought up to date
+// with the state of every new head (see todo RemoveInvalid). If a journal was
+// opened it is rewritten every Rejournal (See todo OpenJournal).
 func (pool *TxPool) Start() {
 	//go pool.queueHandler()
+	pool.events = pool.eventMux.Subscribe(ChainSplitEvent{}, ChainHeadEvent{})
+	go pool.eventLoop()
+
+	if pool.journal != nil {
+		go pool.journalLoop()
+	}
+}
+
+func (pool *TxPool) eventLoop() {
+	for ev := range pool.events.Chan() {
+		switch ev := ev.(type) {
+		case ChainSplitEvent:
+			if n := pool.Readd(ev.Removed); n > 0 {
+				txplogger.Infof("re-added %d transactions of %d dropped blocks\n", n, len(ev.OldChain))
+			}
+		case ChainHeadEvent:
+			pool.RemoveInvalid(pool.chainManager.State())
+		}
+	}
+}
+
+// todo Readd puts transactions which were included in blocks that are no
+// longer part of the canonical chain back into the pool. Unlike todo Add
+// the transactions are not broadcasted, they are already known to the
+// network. Transactions which are already in the pool or no longer valid
+// are skipped. Returns the number of transactions added.
+func (pool *TxPool) Readd(txs types.Transactions) int {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var added int
+	for _, tx := range txs {
+		if pool.addTransaction(tx, false) == nil {
+			added++
+		}
+	}
+
+	return added
+}
+
+// todo OpenJournal reads the local transactions of the journal at _path_ back into
+// the pool and keeps the local transactions added from now on in it (See todo AddLocal).
+// Transactions of the journal which are no longer valid, e.g. because they were
+// included in the chain meanwhile, are dropped. The journal is rewritten right away,
+// then every Rejournal once the pool is started and on todo Stop, holding just the
+// local transactions still in the pool.
+// Must be called before todo Start.
+func (pool *TxPool) OpenJournal(path string) error {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.journal = newTxJournal(path)
+
+	total, rejected, err := pool.journal.load(func(tx *types.Transaction) error {
+		return pool.addTransaction(tx, true)
+	})
+	if total > 0 {
+		txplogger.Infof("loaded %d local transactions from journal, %d dropped\n", total-rejected, rejected)
+	}
+	if err != nil {
+		txplogger.Warnln("failed to load transaction journal:", err)
+	}
+
+	return pool.rotateJournal()
 }
 
-// todo Stop makes a call on todo Flush to empty the caller's transactions list
-// and then sends the message "Stopped" to the todo txplogger channel.
+func (pool *TxPool) journalLoop() {
+	ticker := time.NewTicker(pool.Rejournal)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pool.mutex.Lock()
+			if err := pool.rotateJournal(); err != nil {
+				txplogger.Warnln("failed to rotate transaction journal:", err)
+			}
+			pool.mutex.Unlock()
+		case <-pool.quit:
+			return
+		}
+	}
+}
+
+// todo rotateJournal is an inner function which rewrites the journal with the local
+// transactions of the pool, each sender's in nonce order.
+// todo not locked.
+func (pool *TxPool) rotateJournal() error {
+	var txs types.Transactions
+	for _, lists := range []map[string]*txList{pool.pending, pool.queue} {
+		for _, list := range lists {
+			for _, tx := range list.Flatten() {
+				if pool.locals[string(tx.Hash())] {
+					txs = append(txs, tx)
+				}
+			}
+		}
+	}
+
+	return pool.journal.rotate(txs)
+}
+
+// todo Stop unsubscribes the pool from chain events, rewrites and closes the journal
+// of local transactions if one was opened, makes a call on todo Flush to empty the
+// caller's transactions list and then sends the message "Stopped" to the todo
+// txplogger channel.
 func (pool *TxPool) Stop() {
+	if pool.events != nil {
+		pool.events.Unsubscribe()
+	}
+	close(pool.quit)
+
+	if pool.journal != nil {
+		pool.mutex.Lock()
+		if err := pool.rotateJournal(); err != nil {
+			txplogger.Warnln("failed to rotate transaction journal:", err)
+		}
+		pool.journal.close()
+		pool.mutex.Unlock()
+	}
 	pool.Flush()
 
 	txplogger.Infoln("Stopped")
@@ -0,0 +1,356 @@
+package core
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethdb"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/event"
+)
+
+// Returns an empty pool on top of a new chain and funds the accounts of the given keys
+func newTestTxPool(keys ...*crypto.KeyPair) *TxPool {
+	db, _ := ethdb.NewMemDatabase()
+	chainMan := newChainManagerWithDb(db)
+	for _, key := range keys {
+		chainMan.State().GetOrNewStateObject(key.Address()).AddBalance(ethutil.BigPow(10, 18))
+	}
+
+	pool := NewTxPool(chainMan, nil, new(event.TypeMux))
+	pool.MinGasPrice = big.NewInt(1)
+
+	return pool
+}
+
+func testTransaction(key *crypto.KeyPair, nonce uint64, gasPrice int64) *types.Transaction {
+	tx := types.NewTransactionMessage(make([]byte, 20), big.NewInt(1), big.NewInt(21000), big.NewInt(gasPrice), nil)
+	tx.SetNonce(nonce)
+	tx.Sign(key.PrivateKey)
+
+	return tx
+}
+
+func TestTxPoolQueue(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	key := crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(key)
+	from := string(key.Address())
+
+	count := func(list *txList) int {
+		if list == nil {
+			return 0
+		}
+		return list.Len()
+	}
+	check := func(pending, queued int) {
+		if n := count(pool.pending[from]); n != pending {
+			t.Errorf("%d pending transactions, expected %d", n, pending)
+		}
+		if n := count(pool.queue[from]); n != queued {
+			t.Errorf("%d queued transactions, expected %d", n, queued)
+		}
+		if pool.Size() != pending+queued {
+			t.Errorf("pool size %d, expected %d", pool.Size(), pending+queued)
+		}
+	}
+
+	txs := types.Transactions{testTransaction(key, 0, 1), testTransaction(key, 1, 1), testTransaction(key, 2, 1), testTransaction(key, 3, 1)}
+	for _, i := range []int{0, 2, 3} {
+		if err := pool.Add(txs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check(1, 2)
+
+	if err := pool.Add(txs[0]); err == nil {
+		t.Error("expected error adding known transaction")
+	}
+	if err := pool.Add(testTransaction(key, 2, 1)); err == nil {
+		t.Error("expected error adding transaction with a used nonce")
+	}
+
+	// Filling the gap promotes the queued transactions
+	if err := pool.Add(txs[1]); err != nil {
+		t.Fatal(err)
+	}
+	check(4, 0)
+
+	// Removing a transaction queues the following ones again
+	pool.RemoveSet(types.Transactions{txs[1]})
+	check(1, 2)
+
+	// Transactions below the account's nonce are dropped, the rest is promoted
+	pool.chainManager.State().SetNonce(key.Address(), 2)
+	pool.RemoveInvalid(pool.chainManager.State())
+	check(2, 0)
+
+	if err := pool.Add(txs[1]); !IsNonceErr(err) {
+		t.Errorf("expected nonce error, got %v", err)
+	}
+}
+
+func TestTxPoolPendingOrder(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	key1, key2 := crypto.GenerateNewKeyPair(), crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(key1, key2)
+
+	txs := types.Transactions{
+		testTransaction(key2, 0, 3),
+		testTransaction(key2, 1, 2),
+		testTransaction(key1, 0, 1),
+		testTransaction(key1, 1, 5),
+	}
+	for _, tx := range txs {
+		if err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Not executable, never part of the pending transactions
+	if err := pool.Add(testTransaction(key1, 3, 10)); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := pool.PendingTransactions()
+	if len(pending) != len(txs) {
+		t.Fatalf("%d pending transactions, expected %d", len(pending), len(txs))
+	}
+	for i, tx := range pending {
+		if !bytes.Equal(tx.Hash(), txs[i].Hash()) {
+			t.Errorf("pending transaction %d: got nonce %d price %v, expected nonce %d price %v", i, tx.Nonce(), tx.GasPrice(), txs[i].Nonce(), txs[i].GasPrice())
+		}
+	}
+}
+
+func TestTxPoolReplace(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	key := crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(key)
+
+	if err := pool.Add(testTransaction(key, 0, 0)); err == nil {
+		t.Error("expected error adding transaction below the minimum gas price")
+	}
+
+	for _, tx := range []*types.Transaction{testTransaction(key, 0, 100), testTransaction(key, 1, 100)} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The replacement must outbid the pooled transaction by PriceBump percent
+	if err := pool.Add(testTransaction(key, 0, 109)); err == nil {
+		t.Error("expected error replacing transaction with too low gas price")
+	}
+	replacement := testTransaction(key, 0, 110)
+	if err := pool.Add(replacement); err != nil {
+		t.Fatal(err)
+	}
+
+	pending := pool.PendingTransactions()
+	if len(pending) != 2 || !bytes.Equal(pending[0].Hash(), replacement.Hash()) {
+		t.Errorf("pending transactions %v, expected replacement followed by nonce 1", pending)
+	}
+	if pool.Size() != 2 {
+		t.Errorf("pool size %d, expected 2", pool.Size())
+	}
+}
+
+func TestTxPoolEviction(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	key1, key2 := crypto.GenerateNewKeyPair(), crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(key1, key2)
+	pool.GlobalSlots = 4
+	pool.AccountSlots = 3
+
+	for i := uint64(0); i < 3; i++ {
+		if err := pool.Add(testTransaction(key1, i, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The account is full, a cheaper transaction is rejected, a pricier one evicts the last
+	if err := pool.Add(testTransaction(key1, 3, 5)); err == nil {
+		t.Error("expected error adding cheapest transaction to full account")
+	}
+	if err := pool.Add(testTransaction(key1, 3, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if n := pool.count(string(key1.Address())); n != 3 {
+		t.Errorf("account holds %d transactions, expected 3", n)
+	}
+	if tx := pool.get(string(key1.Address()), 2); tx != nil {
+		t.Error("expected transaction with highest nonce of equal price to be evicted")
+	}
+
+	// The pool is full, the cheapest transaction of any account is evicted
+	if err := pool.Add(testTransaction(key2, 0, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.Add(testTransaction(key2, 1, 15)); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Size() != 4 {
+		t.Errorf("pool size %d, expected 4", pool.Size())
+	}
+	if tx := pool.get(string(key2.Address()), 0); tx != nil {
+		t.Error("expected cheapest transaction to be evicted")
+	}
+}
+
+func TestTxPoolValidation(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	key := crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(key)
+	pool.chainManager.State().SetNonce(key.Address(), 1)
+
+	newTx := func(key *crypto.KeyPair, nonce uint64, gas *big.Int, data []byte) *types.Transaction {
+		tx := types.NewTransactionMessage(make([]byte, 20), big.NewInt(1), gas, big.NewInt(1), data)
+		tx.SetNonce(nonce)
+		tx.Sign(key.PrivateKey)
+
+		return tx
+	}
+
+	gasLimit := pool.chainManager.CurrentBlock().GasLimit
+	if err := pool.ValidateTransaction(newTx(key, 1, big.NewInt(21000), make([]byte, maxTxDataSize+1))); !IsOversizedDataErr(err) {
+		t.Errorf("expected oversized data error, got %v", err)
+	}
+	if err := pool.ValidateTransaction(newTx(key, 1, new(big.Int).Add(gasLimit, ethutil.Big1), nil)); !IsGasLimitErr(err) {
+		t.Errorf("expected gas limit error, got %v", err)
+	}
+	if err := pool.ValidateTransaction(newTx(key, 1, IntrinsicGas([]byte{1}), []byte{1, 0})); !IsIntrinsicGasErr(err) {
+		t.Errorf("expected intrinsic gas error, got %v", err)
+	}
+	if err := pool.ValidateTransaction(newTx(key, 0, big.NewInt(21000), nil)); !IsNonceErr(err) {
+		t.Errorf("expected nonce error, got %v", err)
+	}
+	if err := pool.ValidateTransaction(newTx(crypto.GenerateNewKeyPair(), 0, big.NewInt(21000), nil)); !IsInsufficientFundsErr(err) {
+		t.Errorf("expected insufficient funds error, got %v", err)
+	}
+
+	if err := pool.ValidateTransaction(newTx(key, 1, IntrinsicGas([]byte{1, 0}), []byte{1, 0})); err != nil {
+		t.Error(err)
+	}
+	// Transactions with a future nonce are valid, the pool queues them
+	if err := pool.ValidateTransaction(newTx(key, 5, big.NewInt(21000), nil)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTxPoolJournal(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	dir, err := ioutil.TempDir("", "txjournal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	journal := filepath.Join(dir, "transactions.rlp")
+
+	local, remote := crypto.GenerateNewKeyPair(), crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(local, remote)
+	if err := pool.OpenJournal(journal); err != nil {
+		t.Fatal(err)
+	}
+	pool.GlobalSlots = 2
+
+	for i := uint64(0); i < 2; i++ {
+		if err := pool.AddLocal(testTransaction(local, i, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Local transactions are never evicted, however cheap they are
+	if err := pool.Add(testTransaction(remote, 0, 100)); err == nil {
+		t.Error("expected error adding transaction to pool full of local transactions")
+	}
+	pool.Stop()
+
+	// The journal is replayed into a new pool, dropping the transactions included meanwhile
+	pool = newTestTxPool(local, remote)
+	pool.chainManager.State().SetNonce(local.Address(), 1)
+	if err := pool.OpenJournal(journal); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Size() != 1 || pool.get(string(local.Address()), 1) == nil {
+		t.Errorf("pool holds %d transactions after loading journal, expected local transaction with nonce 1", pool.Size())
+	}
+	if err := pool.AddLocal(testTransaction(local, 2, 1)); err != nil {
+		t.Fatal(err)
+	}
+	pool.Stop()
+
+	pool = newTestTxPool(local, remote)
+	pool.chainManager.State().SetNonce(local.Address(), 1)
+	if err := pool.OpenJournal(journal); err != nil {
+		t.Fatal(err)
+	}
+	if pool.Size() != 2 {
+		t.Errorf("pool holds %d transactions after loading journal, expected 2", pool.Size())
+	}
+	pool.Stop()
+}
+
+func TestTxPoolInspect(t *testing.T) {
+	defer func(prev ethutil.Database) { ethutil.Config.Db = prev }(ethutil.Config.Db)
+
+	key1, key2 := crypto.GenerateNewKeyPair(), crypto.GenerateNewKeyPair()
+	pool := newTestTxPool(key1, key2)
+	pool.chainManager.State().SetNonce(key2.Address(), 3)
+
+	txs := types.Transactions{testTransaction(key1, 0, 1), testTransaction(key1, 2, 1), testTransaction(key2, 5, 1)}
+	for _, tx := range txs {
+		if err := pool.Add(tx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := pool.AddLocal(testTransaction(key1, 1, 2)); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := pool.Inspect()
+	if len(accounts) != 2 {
+		t.Fatalf("%d accounts, expected 2", len(accounts))
+	}
+	if bytes.Compare(accounts[0].Address, accounts[1].Address) > 0 {
+		t.Error("accounts not ordered by address")
+	}
+
+	for _, acc := range accounts {
+		var pending, queued []uint64
+		for _, status := range acc.Pending {
+			pending = append(pending, status.Tx.Nonce())
+			if status.Added.IsZero() {
+				t.Errorf("transaction %d of %x without time added", status.Tx.Nonce(), acc.Address[:4])
+			}
+			if status.Local != (status.Tx.Nonce() == 1 && bytes.Equal(acc.Address, key1.Address())) {
+				t.Errorf("transaction %d of %x local: %v", status.Tx.Nonce(), acc.Address[:4], status.Local)
+			}
+		}
+		for _, status := range acc.Queued {
+			queued = append(queued, status.Tx.Nonce())
+		}
+
+		switch {
+		case bytes.Equal(acc.Address, key1.Address()):
+			if acc.Nonce != 0 || len(pending) != 3 || len(queued) != 0 || pending[0] != 0 || pending[2] != 2 {
+				t.Errorf("account 1: nonce %d, pending %v, queued %v", acc.Nonce, pending, queued)
+			}
+		case bytes.Equal(acc.Address, key2.Address()):
+			if acc.Nonce != 3 || len(pending) != 0 || len(queued) != 1 || queued[0] != 5 {
+				t.Errorf("account 2: nonce %d, pending %v, queued %v", acc.Nonce, pending, queued)
+			}
+		default:
+			t.Errorf("unexpected account %x", acc.Address)
+		}
+	}
+}
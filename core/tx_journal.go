@@ -0,0 +1,109 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/rlp"
+)
+
+// A file of locally submitted transactions which survives restarts of the
+// node. Transactions are appended one after another, each as its own RLP
+// encoded value (like ChainManager.ExportRange writes blocks), so a write
+// cut short by a crash only loses the last transaction.
+type txJournal struct {
+	path   string
+	writer *os.File
+}
+
+func newTxJournal(path string) *txJournal {
+	return &txJournal{path: path}
+}
+
+// Reads the transactions of the journal and hands them to add. A missing
+// journal is not an error. Returns the number of transactions read and the
+// number add rejected.
+func (self *txJournal) load(add func(*types.Transaction) error) (int, int, error) {
+	file, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return 0, 0, nil
+	} else if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	var (
+		stream          = rlp.NewStream(file)
+		total, rejected int
+	)
+	for {
+		data, err := stream.Raw()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return total, rejected, fmt.Errorf("transaction %d of journal: %v", total, err)
+		}
+
+		total++
+		if add(types.NewTransactionFromBytes(data)) != nil {
+			rejected++
+		}
+	}
+
+	return total, rejected, nil
+}
+
+// Appends the transaction to the journal, which must have been opened by rotate
+func (self *txJournal) insert(tx *types.Transaction) error {
+	if self.writer == nil {
+		return fmt.Errorf("transaction journal %s not open", self.path)
+	}
+
+	_, err := self.writer.Write(tx.RlpEncode())
+
+	return err
+}
+
+// Replaces the journal by one holding just the given transactions and opens
+// it for appending. The new journal is written next to the old one first, so
+// a crash doesn't leave a partial journal behind.
+func (self *txJournal) rotate(txs types.Transactions) error {
+	if self.writer != nil {
+		self.writer.Close()
+		self.writer = nil
+	}
+
+	tmp, err := os.OpenFile(self.path+".new", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	for _, tx := range txs {
+		if _, err := tmp.Write(tx.RlpEncode()); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	tmp.Close()
+
+	if err := os.Rename(self.path+".new", self.path); err != nil {
+		return err
+	}
+
+	self.writer, err = os.OpenFile(self.path, os.O_WRONLY|os.O_APPEND, 0644)
+
+	return err
+}
+
+// Closes the journal file
+func (self *txJournal) close() error {
+	if self.writer == nil {
+		return nil
+	}
+
+	err := self.writer.Close()
+	self.writer = nil
+
+	return err
+}
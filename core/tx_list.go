@@ -0,0 +1,112 @@
+package core
+
+import (
+	"sort"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+)
+
+// The transactions of a single account in the TxPool, by nonce. A TxPool
+// keeps one txList per account for its pending and one for its queued
+// transactions.
+type txList struct {
+	items map[uint64]*types.Transaction
+}
+
+func newTxList() *txList {
+	return &txList{items: make(map[uint64]*types.Transaction)}
+}
+
+// Returns the number of transactions in the list
+func (self *txList) Len() int {
+	return len(self.items)
+}
+
+// Returns the transaction with the given nonce, or nil
+func (self *txList) Get(nonce uint64) *types.Transaction {
+	return self.items[nonce]
+}
+
+// Inserts the transaction, replacing any transaction with the same nonce
+func (self *txList) Put(tx *types.Transaction) {
+	self.items[tx.Nonce()] = tx
+}
+
+// Removes the transaction with the given nonce and returns whether it was in the list
+func (self *txList) Remove(nonce uint64) bool {
+	if self.items[nonce] == nil {
+		return false
+	}
+	delete(self.items, nonce)
+
+	return true
+}
+
+// Removes and returns the transactions with a nonce below the given one
+func (self *txList) Forward(nonce uint64) types.Transactions {
+	return self.filter(func(tx *types.Transaction) bool { return tx.Nonce() < nonce })
+}
+
+// Removes and returns the transactions with a nonce above the given one
+func (self *txList) Cap(nonce uint64) types.Transactions {
+	return self.filter(func(tx *types.Transaction) bool { return tx.Nonce() > nonce })
+}
+
+// Removes and returns the transactions with consecutive nonces starting at
+// the given one, in nonce order.
+func (self *txList) Ready(nonce uint64) (ready types.Transactions) {
+	for tx := self.items[nonce]; tx != nil; tx = self.items[nonce] {
+		ready = append(ready, tx)
+		delete(self.items, nonce)
+		nonce++
+	}
+
+	return
+}
+
+// Returns the transactions of the list in nonce order
+func (self *txList) Flatten() types.Transactions {
+	txs := make(types.Transactions, 0, len(self.items))
+	for _, tx := range self.items {
+		txs = append(txs, tx)
+	}
+	sort.Sort(types.TxByNonce{Transactions: txs})
+
+	return txs
+}
+
+// Inner function that removes and returns the transactions matching the
+// given function, in nonce order.
+func (self *txList) filter(match func(*types.Transaction) bool) types.Transactions {
+	var removed types.Transactions
+	for nonce, tx := range self.items {
+		if match(tx) {
+			removed = append(removed, tx)
+			delete(self.items, nonce)
+		}
+	}
+	sort.Sort(types.TxByNonce{Transactions: removed})
+
+	return removed
+}
+
+// A heap of transactions of different accounts, highest gas price first.
+// Used to order the pending transactions of the TxPool by gas price.
+type txPriceHeap types.Transactions
+
+func (h txPriceHeap) Len() int           { return len(h) }
+func (h txPriceHeap) Less(i, j int) bool { return h[i].GasPrice().Cmp(h[j].GasPrice()) > 0 }
+func (h txPriceHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *txPriceHeap) Push(x interface{}) {
+	*h = append(*h, x.(*types.Transaction))
+}
+
+func (h *txPriceHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	tx := old[n-1]
+	*h = old[:n-1]
+
+	return tx
+}
@@ -244,13 +244,16 @@ func (block *Block) Transactions() Transactions {
 	return block.transactions
 }
 
+// The lowest gas limit a block may have
+var MinGasLimit = big.NewInt(125000)
+
 // Calculates the gas limit.
 //
 // If the Block passed as a parameter is the genesis block the gas limit is set to 10^6.
 //
 // Otherwise the gas limit will be ~= 1023 * parent.GasLimit + parent.GasUsed*6/5
 //
-// The minimum gas limit is set to 125000.
+// The minimum gas limit is set to MinGasLimit.
 func (block *Block) CalcGasLimit(parent *Block) *big.Int {
 	if block.Number.Cmp(big.NewInt(0)) == 0 {
 		return ethutil.BigPow(10, 6)
@@ -265,9 +268,7 @@ func (block *Block) CalcGasLimit(parent *Block) *big.Int {
 	result := new(big.Int).Add(previous, curInt)
 	result.Div(result, big.NewInt(1024))
 
-	min := big.NewInt(125000)
-
-	return ethutil.BigMax(min, result)
+	return ethutil.BigMax(new(big.Int).Set(MinGasLimit), result)
 }
 
 // Returns the BlockInfo representation of a Block.
@@ -293,8 +294,8 @@ func (self *Block) GetTransaction(hash []byte) *Transaction {
 
 // Sync the block's state and contract respectively.
 // For more, see the state package of this go-ethereum version.
-func (block *Block) Sync() {
-	block.state.Sync()
+func (block *Block) Sync() error {
+	return block.state.Sync()
 }
 
 // Resets the state to nil.
@@ -332,7 +333,12 @@ func (block *Block) rlpUncles() interface{} {
 // Also sets the UncleSha of the Block based on the provided parameter. To do so, an inner function called rlpUncles() is used.
 func (block *Block) SetUncles(uncles []*Block) {
 	block.Uncles = uncles
-	block.UncleSha = crypto.Sha3(ethutil.Encode(block.rlpUncles()))
+	block.UncleSha = block.CalcUncleSha()
+}
+
+// Returns the hash of the block's uncles, which is what SetUncles stores in UncleSha.
+func (block *Block) CalcUncleSha() []byte {
+	return crypto.Sha3(ethutil.Encode(block.rlpUncles()))
 }
 
 // Sets the receipts of a Block to the parameter 'receipts'.
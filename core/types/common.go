@@ -3,6 +3,7 @@ package types
 import (
 	"math/big"
 
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/wire"
 )
@@ -11,9 +12,12 @@ import (
 // calculates and returns in that order: the total difficulty of the block as a bigInt, the
 // messages of the block (aka the transactions) and/or an error.
 //
+// The resulting state of the block is not written to the database but added to the given batch,
+// so that the caller can persist it together with the block itself.
+//
 // In case of an error the values returned by the function Process for the td and messages are nil.
 type BlockProcessor interface {
-	Process(*Block) (*big.Int, state.Messages, error)
+	Process(*Block, ethutil.Batch) (*big.Int, state.Messages, error)
 }
 
 // A Broadcaster is a type that can broadcast messages of a given type to a list of recipients.
@@ -40,6 +40,11 @@ func NewRecieptFromValue(val *ethutil.Value) *Receipt {
 	return r
 }
 
+// Returns the logs field of the Receipt.
+func (self *Receipt) Logs() state.Logs {
+	return self.logs
+}
+
 // Sets the Receipt logs field equal to the 'logs' parameter.
 func (self *Receipt) SetLogs(logs state.Logs) {
 	self.logs = logs
@@ -99,6 +104,18 @@ func (self Receipts) RlpData() interface{} {
 	return data
 }
 
+// Creates a new Receipts object from an rlp-encoded ethutil.Value object 'val', the rlp-encoded list of receipts.
+func NewReceiptsFromValue(val *ethutil.Value) Receipts {
+	receipts := make(Receipts, 0, val.Len())
+
+	it := val.NewIterator()
+	for it.Next() {
+		receipts = append(receipts, NewRecieptFromValue(it.Value()))
+	}
+
+	return receipts
+}
+
 // Returns the rlp-encoding of the caller, aka rlp-encodes each Receipt object that the caller consists of and returns
 // the result.
 func (self Receipts) RlpEncode() []byte {
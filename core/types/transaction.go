@@ -46,6 +46,9 @@ type Transaction struct {
 	data      []byte
 	v         byte
 	r, s      []byte
+
+	// sender recovered from the signature, see Sender
+	from []byte
 }
 
 // Creates and returns a new Transaction which represents the creation of a contract. The Transaction's 'recipient' field
@@ -115,6 +118,7 @@ func (self *Transaction) Nonce() uint64 {
 // Sets the caller's nonce field equal to the 'nonce' parameter.
 func (self *Transaction) SetNonce(nonce uint64) {
 	self.nonce = nonce
+	self.from = nil
 }
 
 // Returns the sender of the transaction.
@@ -165,12 +169,21 @@ func (tx *Transaction) PublicKey() []byte {
 
 // Returns the sender of the transaction. To do so, the public key of the transaction is retrieved first through the function
 // Transaction.PublicKey(). If the public key passes validation then the last 12 bytes of the public key are returned (aka the sender address)
+//
+// Recovering the public key is expensive, so the sender is remembered once recovered. Changing or decoding the
+// transaction forgets it again.
 func (tx *Transaction) Sender() []byte {
+	if tx.from != nil {
+		return tx.from
+	}
+
 	pubkey := tx.PublicKey()
-	if len(pubkey) != 0 && pubkey[0] != 4 {
+	if len(pubkey) == 0 || pubkey[0] != 4 {
 		return nil
 	}
-	return crypto.Sha3(pubkey[1:])[12:]
+	tx.from = crypto.Sha3(pubkey[1:])[12:]
+
+	return tx.from
 }
 
 // Signes the transaction. To do so, the function Transaction.Signature is called, which makes use of a non-existent package. Refer to
@@ -189,6 +202,7 @@ func (tx *Transaction) Sign(privk []byte) error {
 	tx.r = sig[:32]
 	tx.s = sig[32:64]
 	tx.v = sig[64] + 27
+	tx.from = nil
 
 	return nil
 }
@@ -230,6 +244,7 @@ func (tx *Transaction) RlpValueDecode(decoder *ethutil.Value) {
 
 	tx.r = decoder.Get(7).Bytes()
 	tx.s = decoder.Get(8).Bytes()
+	tx.from = nil
 }
 
 // Returns the string representation of the caller.
@@ -26,8 +26,7 @@ func (k *DBKeyStore) dbKey(session string) []byte {
 }
 
 func (k *DBKeyStore) Save(session string, keyRing *KeyRing) error {
-	k.db.Put(k.dbKey(session), keyRing.RlpEncode())
-	return nil
+	return k.db.Put(k.dbKey(session), keyRing.RlpEncode())
 }
 
 func (k *DBKeyStore) Load(session string) (*KeyRing, error) {
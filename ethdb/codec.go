@@ -0,0 +1,113 @@
+package ethdb
+
+import (
+	"fmt"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/compression/rle"
+	"github.com/golang/snappy"
+)
+
+// A Codec compresses values before they are written to the database and
+// decompresses them again when they are read.
+//
+// Every value written by a LDBDatabase is prefixed with the one-byte tag of
+// the codec that encoded it. Values are always decoded with the codec their
+// tag names, so the codec of a database can be changed without re-encoding
+// what has already been stored.
+type Codec interface {
+	Tag() byte
+	Name() string
+	Encode(data []byte) []byte
+	Decode(data []byte) ([]byte, error)
+}
+
+// Tags of the built-in codecs. These are persisted and must never change.
+const (
+	NoneTag byte = iota
+	RleTag
+	SnappyTag
+)
+
+// The codec used when none is given explicitly
+const DefaultCodec = "rle"
+
+var (
+	codecs      = make(map[byte]Codec)
+	codecsNamed = make(map[string]Codec)
+)
+
+func init() {
+	RegisterCodec(noneCodec{})
+	RegisterCodec(rleCodec{})
+	RegisterCodec(snappyCodec{})
+}
+
+// Makes a codec available by its tag and name. Registering a codec with a
+// tag or name that is already in use panics.
+func RegisterCodec(codec Codec) {
+	if _, ok := codecs[codec.Tag()]; ok {
+		panic(fmt.Sprintf("ethdb: codec tag %d registered twice", codec.Tag()))
+	}
+	if _, ok := codecsNamed[codec.Name()]; ok {
+		panic(fmt.Sprintf("ethdb: codec %q registered twice", codec.Name()))
+	}
+
+	codecs[codec.Tag()] = codec
+	codecsNamed[codec.Name()] = codec
+}
+
+// Returns the codec registered under the given name.
+func CodecByName(name string) (Codec, error) {
+	codec, ok := codecsNamed[name]
+	if !ok {
+		return nil, fmt.Errorf("ethdb: unknown codec %q", name)
+	}
+
+	return codec, nil
+}
+
+// Encodes the value with the given codec and prefixes it with the codec's tag.
+func encodeValue(codec Codec, value []byte) []byte {
+	enc := codec.Encode(value)
+
+	tagged := make([]byte, len(enc)+1)
+	tagged[0] = codec.Tag()
+	copy(tagged[1:], enc)
+
+	return tagged
+}
+
+// Decodes a tagged value with the codec its tag names.
+func decodeValue(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("ethdb: value without codec tag")
+	}
+
+	codec, ok := codecs[data[0]]
+	if !ok {
+		return nil, fmt.Errorf("ethdb: unknown codec tag %d", data[0])
+	}
+
+	return codec.Decode(data[1:])
+}
+
+type noneCodec struct{}
+
+func (noneCodec) Tag() byte                          { return NoneTag }
+func (noneCodec) Name() string                       { return "none" }
+func (noneCodec) Encode(data []byte) []byte          { return data }
+func (noneCodec) Decode(data []byte) ([]byte, error) { return data, nil }
+
+type rleCodec struct{}
+
+func (rleCodec) Tag() byte                          { return RleTag }
+func (rleCodec) Name() string                       { return "rle" }
+func (rleCodec) Encode(data []byte) []byte          { return rle.Compress(data) }
+func (rleCodec) Decode(data []byte) ([]byte, error) { return rle.Decompress(data) }
+
+type snappyCodec struct{}
+
+func (snappyCodec) Tag() byte                          { return SnappyTag }
+func (snappyCodec) Name() string                       { return "snappy" }
+func (snappyCodec) Encode(data []byte) []byte          { return snappy.Encode(nil, data) }
+func (snappyCodec) Decode(data []byte) ([]byte, error) { return snappy.Decode(nil, data) }
@@ -0,0 +1,139 @@
+package ethdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/compression/rle"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func setupDatadir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ethdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ethutil.ReadConfig(path.Join(dir, "conf.ini"), dir, "ETH")
+	ethutil.Config.ExecPath = dir
+
+	return dir
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	in := append(make([]byte, 40), []byte("dog")...)
+	for _, name := range []string{"none", "rle", "snappy"} {
+		codec, err := CodecByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		enc := encodeValue(codec, in)
+		if enc[0] != codec.Tag() {
+			t.Errorf("%s: expected tag %d, got %d", name, codec.Tag(), enc[0])
+		}
+
+		out, err := decodeValue(enc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(in, out) {
+			t.Errorf("%s: expected %x, got %x", name, in, out)
+		}
+	}
+
+	if _, err := CodecByName("lz4"); err == nil {
+		t.Error("expected error for unknown codec")
+	}
+	if _, err := decodeValue([]byte{0xaa, 0x1}); err == nil {
+		t.Error("expected error for unknown codec tag")
+	}
+}
+
+func TestLDBDatabaseSwitchCodec(t *testing.T) {
+	dir := setupDatadir(t)
+	defer os.RemoveAll(dir)
+
+	db, err := NewLDBDatabaseWithCodec("switch", "rle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.Put([]byte("a"), []byte("rle value"))
+	db.Close()
+
+	db, err = NewLDBDatabaseWithCodec("switch", "snappy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.Put([]byte("b"), []byte("snappy value"))
+
+	if data, _ := db.Get([]byte("a")); !bytes.Equal(data, []byte("rle value")) {
+		t.Errorf("a: got %q", data)
+	}
+	if data, _ := db.Get([]byte("b")); !bytes.Equal(data, []byte("snappy value")) {
+		t.Errorf("b: got %q", data)
+	}
+}
+
+func TestLDBDatabaseLegacyMigration(t *testing.T) {
+	dir := setupDatadir(t)
+	defer os.RemoveAll(dir)
+
+	// Database as written before values were tagged
+	raw, err := leveldb.OpenFile(path.Join(dir, "legacy"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string][]byte{
+		"LTD":       {0x1, 0x0},
+		"LastBlock": append(make([]byte, 32), 0xfe, 0x1),
+	}
+	for k, v := range values {
+		raw.Put([]byte(k), rle.Compress(v), nil)
+	}
+	raw.Close()
+
+	db, err := NewLDBDatabaseWithCodec("legacy", "snappy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db.legacy {
+		t.Error("expected legacy database")
+	}
+	for k, v := range values {
+		if data, _ := db.Get([]byte(k)); !bytes.Equal(data, v) {
+			t.Errorf("legacy %s: expected %x, got %x", k, v, data)
+		}
+	}
+	db.Close()
+
+	count, err := MigrateCodec("legacy", "snappy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(values) {
+		t.Errorf("expected %d migrated values, got %d", len(values), count)
+	}
+
+	db, err = NewLDBDatabaseWithCodec("legacy", "snappy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if db.legacy {
+		t.Error("expected tagged database after migration")
+	}
+	for k, v := range values {
+		if data, _ := db.Get([]byte(k)); !bytes.Equal(data, v) {
+			t.Errorf("migrated %s: expected %x, got %x", k, v, data)
+		}
+		if data, _ := db.db.Get([]byte(k), nil); data[0] != SnappyTag {
+			t.Errorf("migrated %s: expected snappy tag, got %d", k, data[0])
+		}
+	}
+}
@@ -6,16 +6,44 @@ import (
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/compression/rle"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
+var dblogger = logger.NewLogger("DB")
+
+// Databases which store a codec tag in front of every value have this key set.
+// Older databases hold untagged rle values only.
+var codecTagsKey = []byte("CodecTags")
+
+// LDBDatabase is a leveldb backed database
+//
+// codec: the codec used to encode values on writes.
+//
+// legacy: set for databases created before values were tagged. All values of
+// such a database are read and written as untagged rle until it is migrated
+// (see MigrateCodec).
 type LDBDatabase struct {
-	db   *leveldb.DB
-	comp bool
+	db     *leveldb.DB
+	codec  Codec
+	legacy bool
 }
 
+// Opens the database with the default codec
 func NewLDBDatabase(name string) (*LDBDatabase, error) {
+	return NewLDBDatabaseWithCodec(name, DefaultCodec)
+}
+
+// Opens the database with the given name in the datadir. New values are
+// encoded with the named codec.
+func NewLDBDatabaseWithCodec(name string, codecName string) (*LDBDatabase, error) {
+	codec, err := CodecByName(codecName)
+	if err != nil {
+		return nil, err
+	}
+
 	dbPath := path.Join(ethutil.Config.ExecPath, name)
 
 	// Open the db
@@ -24,20 +52,57 @@ func NewLDBDatabase(name string) (*LDBDatabase, error) {
 		return nil, err
 	}
 
-	database := &LDBDatabase{db: db, comp: true}
+	database := &LDBDatabase{db: db, codec: codec}
+
+	if _, err := db.Get(codecTagsKey, nil); err != nil {
+		it := db.NewIterator(nil, nil)
+		empty := !it.Next()
+		it.Release()
+
+		if empty {
+			// Fresh database, tag values from the start
+			if err := db.Put(codecTagsKey, encodeValue(noneCodec{}, []byte{1}), nil); err != nil {
+				db.Close()
+				return nil, err
+			}
+		} else {
+			database.legacy = true
+			if codec.Tag() != RleTag {
+				dblogger.Warnf("database '%s' holds untagged rle values. Codec '%s' is not used until the database is migrated\n", name, codec.Name())
+			}
+		}
+	}
 
 	return database, nil
 }
 
-func (self *LDBDatabase) Put(key []byte, value []byte) {
-	if self.comp {
-		value = rle.Compress(value)
+// Returns the codec used for newly written values
+func (self *LDBDatabase) Codec() Codec {
+	if self.legacy {
+		return rleCodec{}
 	}
 
-	err := self.db.Put(key, value, nil)
-	if err != nil {
-		fmt.Println("Error put", err)
+	return self.codec
+}
+
+func (self *LDBDatabase) encode(value []byte) []byte {
+	if self.legacy {
+		return rle.Compress(value)
+	}
+
+	return encodeValue(self.codec, value)
+}
+
+func (self *LDBDatabase) decode(data []byte) ([]byte, error) {
+	if self.legacy {
+		return rle.Decompress(data)
 	}
+
+	return decodeValue(data)
+}
+
+func (self *LDBDatabase) Put(key []byte, value []byte) error {
+	return self.db.Put(key, self.encode(value), nil)
 }
 
 func (self *LDBDatabase) Get(key []byte) ([]byte, error) {
@@ -46,11 +111,7 @@ func (self *LDBDatabase) Get(key []byte) ([]byte, error) {
 		return nil, err
 	}
 
-	if self.comp {
-		return rle.Decompress(dat)
-	}
-
-	return dat, nil
+	return self.decode(dat)
 }
 
 func (self *LDBDatabase) Delete(key []byte) error {
@@ -67,8 +128,21 @@ func (self *LDBDatabase) LastKnownTD() []byte {
 	return data
 }
 
-func (self *LDBDatabase) NewIterator() iterator.Iterator {
-	return self.db.NewIterator(nil, nil)
+// Returns a new batch which is committed to the database in a single leveldb
+// write. Values are encoded the same way Put encodes them.
+func (self *LDBDatabase) NewBatch() ethutil.Batch {
+	return &ldbBatch{db: self, batch: new(leveldb.Batch)}
+}
+
+// Returns an iterator over the keys within r (all keys if r is nil). The values
+// are decoded the same way Get decodes them.
+func (self *LDBDatabase) NewIterator(r *ethutil.Range) ethutil.Iterator {
+	var slice *util.Range
+	if r != nil {
+		slice = &util.Range{Start: r.Start, Limit: r.Limit}
+	}
+
+	return &ldbIterator{Iterator: self.db.NewIterator(slice, nil), db: self}
 }
 
 func (self *LDBDatabase) Close() {
@@ -77,7 +151,8 @@ func (self *LDBDatabase) Close() {
 }
 
 func (self *LDBDatabase) Print() {
-	iter := self.db.NewIterator(nil, nil)
+	iter := self.NewIterator(nil)
+	defer iter.Release()
 	for iter.Next() {
 		key := iter.Key()
 		value := iter.Value()
@@ -87,3 +162,63 @@ func (self *LDBDatabase) Print() {
 		fmt.Printf("%v\n", node)
 	}
 }
+
+type ldbBatch struct {
+	ethutil.BatchHooks
+	db    *LDBDatabase
+	batch *leveldb.Batch
+}
+
+func (self *ldbBatch) Put(key []byte, value []byte) error {
+	self.batch.Put(key, self.db.encode(value))
+
+	return nil
+}
+
+func (self *ldbBatch) Delete(key []byte) error {
+	self.batch.Delete(key)
+
+	return nil
+}
+
+func (self *ldbBatch) Write() error {
+	if err := self.db.db.Write(self.batch, nil); err != nil {
+		return err
+	}
+	self.batch.Reset()
+	self.Written()
+
+	return nil
+}
+
+type ldbIterator struct {
+	iterator.Iterator
+	db    *LDBDatabase
+	value []byte
+	err   error
+}
+
+func (self *ldbIterator) Next() bool {
+	self.value = nil
+	if self.err != nil || !self.Iterator.Next() {
+		return false
+	}
+
+	if self.value, self.err = self.db.decode(self.Iterator.Value()); self.err != nil {
+		return false
+	}
+
+	return true
+}
+
+func (self *ldbIterator) Value() []byte {
+	return self.value
+}
+
+func (self *ldbIterator) Error() error {
+	if self.err != nil {
+		return self.err
+	}
+
+	return self.Iterator.Error()
+}
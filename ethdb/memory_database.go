@@ -2,14 +2,19 @@ package ethdb
 
 import (
 	"fmt"
+	"sync"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 )
 
 /*
  * This is a test memory database. Do not use for any production it does not get persisted
+ *
+ * The database is safe for concurrent use. Values are copied on the way in and on the way out,
+ * so callers can't change the stored data through a slice they passed to Put or got from Get.
  */
 type MemDatabase struct {
+	mu sync.RWMutex
 	db map[string][]byte
 }
 
@@ -19,16 +24,28 @@ func NewMemDatabase() (*MemDatabase, error) {
 	return db, nil
 }
 
-func (db *MemDatabase) Put(key []byte, value []byte) {
-	db.db[string(key)] = value
+func (db *MemDatabase) Put(key []byte, value []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.db[string(key)] = ethutil.CopyBytes(value)
+
+	return nil
 }
 
-func (db *MemDatabase) Set(key []byte, value []byte) {
-	db.Put(key, value)
+func (db *MemDatabase) Set(key []byte, value []byte) error {
+	return db.Put(key, value)
 }
 
 func (db *MemDatabase) Get(key []byte) ([]byte, error) {
-	return db.db[string(key)], nil
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	if value, ok := db.db[string(key)]; ok {
+		return ethutil.CopyBytes(value), nil
+	}
+
+	return nil, nil
 }
 
 /*
@@ -40,12 +57,52 @@ func (db *MemDatabase) GetKeys() []*ethutil.Key {
 */
 
 func (db *MemDatabase) Delete(key []byte) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
 	delete(db.db, string(key))
 
 	return nil
 }
 
+// Returns a snapshot of the database. The copy and the original don't share
+// any data, writes to either one are not seen by the other. This allows a
+// populated database to be forked, e.g. to run two diverging chains.
+func (db *MemDatabase) Copy() *MemDatabase {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	cpy := &MemDatabase{db: make(map[string][]byte, len(db.db))}
+	for key, value := range db.db {
+		cpy.db[key] = ethutil.CopyBytes(value)
+	}
+
+	return cpy
+}
+
+// Returns the number of keys in the database.
+func (db *MemDatabase) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return len(db.db)
+}
+
+func (db *MemDatabase) NewBatch() ethutil.Batch {
+	return &memBatch{db: db}
+}
+
+func (db *MemDatabase) NewIterator(r *ethutil.Range) ethutil.Iterator {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	return ethutil.NewMemIterator(db.db, r)
+}
+
 func (db *MemDatabase) Print() {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
 	for key, val := range db.db {
 		fmt.Printf("%x(%d): ", key, len(key))
 		node := ethutil.NewValueFromBytes(val)
@@ -57,7 +114,7 @@ func (db *MemDatabase) Close() {
 }
 
 func (db *MemDatabase) LastKnownTD() []byte {
-	data, _ := db.Get([]byte("LastKnownTotalDifficulty"))
+	data, _ := db.Get([]byte("LTD"))
 
 	if len(data) == 0 || data == nil {
 		data = []byte{0x0}
@@ -65,3 +122,45 @@ func (db *MemDatabase) LastKnownTD() []byte {
 
 	return data
 }
+
+type kv struct {
+	k, v []byte
+	del  bool
+}
+
+// memBatch queues changes and applies them to the MemDatabase on Write.
+type memBatch struct {
+	ethutil.BatchHooks
+	db     *MemDatabase
+	writes []kv
+}
+
+func (b *memBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, kv{k: ethutil.CopyBytes(key), v: ethutil.CopyBytes(value)})
+
+	return nil
+}
+
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{k: ethutil.CopyBytes(key), del: true})
+
+	return nil
+}
+
+func (b *memBatch) Write() error {
+	b.db.mu.Lock()
+	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+		} else {
+			b.db.db[string(kv.k)] = kv.v
+		}
+	}
+	b.writes = nil
+	b.db.mu.Unlock()
+
+	// The hooks may use the database themselves
+	b.Written()
+
+	return nil
+}
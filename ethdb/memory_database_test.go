@@ -0,0 +1,128 @@
+package ethdb
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+func TestMemDatabaseBatch(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("stale"), []byte("value"))
+
+	batch := db.NewBatch()
+	batch.Put([]byte("dog"), []byte("puppy"))
+	batch.Put([]byte("cat"), []byte("kitten"))
+	batch.Delete([]byte("stale"))
+
+	if data, _ := db.Get([]byte("dog")); data != nil {
+		t.Errorf("expected no data before write, got %x", data)
+	}
+
+	if err := batch.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := db.Get([]byte("dog")); !bytes.Equal(data, []byte("puppy")) {
+		t.Errorf("dog: expected puppy, got %q", data)
+	}
+	if data, _ := db.Get([]byte("cat")); !bytes.Equal(data, []byte("kitten")) {
+		t.Errorf("cat: expected kitten, got %q", data)
+	}
+	if data, _ := db.Get([]byte("stale")); data != nil {
+		t.Errorf("expected stale to be deleted, got %q", data)
+	}
+}
+
+func TestMemDatabaseIterator(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("LastBlock"), []byte("head"))
+	db.Put([]byte("KeyRingA"), []byte("a"))
+	db.Put([]byte("KeyRingB"), []byte("b"))
+	db.Put([]byte("LTD"), []byte{0x1})
+
+	it := db.NewIterator(ethutil.PrefixRange([]byte("KeyRing")))
+	defer it.Release()
+
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || keys[0] != "KeyRingA" || keys[1] != "KeyRingB" {
+		t.Errorf("expected [KeyRingA KeyRingB], got %v", keys)
+	}
+}
+
+func TestMemDatabaseCopyOnRead(t *testing.T) {
+	db, _ := NewMemDatabase()
+
+	in := []byte("puppy")
+	db.Put([]byte("dog"), in)
+	in[0] = 'g'
+
+	out, _ := db.Get([]byte("dog"))
+	out[1] = 'a'
+
+	if data, _ := db.Get([]byte("dog")); !bytes.Equal(data, []byte("puppy")) {
+		t.Errorf("stored value was modified: %q", data)
+	}
+}
+
+func TestMemDatabaseConcurrent(t *testing.T) {
+	db, _ := NewMemDatabase()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				key := []byte(fmt.Sprintf("%d-%d", i, j))
+				db.Put(key, key)
+				db.Get(key)
+
+				batch := db.NewBatch()
+				batch.Put(append(key, 'b'), key)
+				batch.Write()
+
+				it := db.NewIterator(nil)
+				for it.Next() {
+				}
+				it.Release()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if db.Len() != 2000 {
+		t.Errorf("expected 2000 keys, got %d", db.Len())
+	}
+}
+
+func TestMemDatabaseCopy(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("shared"), []byte("value"))
+
+	cpy := db.Copy()
+	db.Put([]byte("original"), []byte("1"))
+	cpy.Put([]byte("fork"), []byte("2"))
+	cpy.Delete([]byte("shared"))
+
+	if data, _ := db.Get([]byte("shared")); !bytes.Equal(data, []byte("value")) {
+		t.Errorf("original lost shared key, got %q", data)
+	}
+	if data, _ := db.Get([]byte("fork")); data != nil {
+		t.Errorf("original sees write to copy: %q", data)
+	}
+	if data, _ := cpy.Get([]byte("original")); data != nil {
+		t.Errorf("copy sees write to original: %q", data)
+	}
+}
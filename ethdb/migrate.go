@@ -0,0 +1,97 @@
+package ethdb
+
+import (
+	"bytes"
+	"os"
+	"path"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+// Number of values written per batch during a migration
+const migrateBatchSize = 1000
+
+// Re-encodes every value of the database with the given name in the datadir
+// with the named codec and returns the number of values written.
+//
+// The values are copied to a new database which replaces the old one only
+// after everything has been written, so an interrupted migration leaves the
+// original database untouched. The database must not be open elsewhere.
+func MigrateCodec(name string, codecName string) (int, error) {
+	if _, err := CodecByName(codecName); err != nil {
+		return 0, err
+	}
+
+	var (
+		srcPath = path.Join(ethutil.Config.ExecPath, name)
+		tmpName = name + ".migrate"
+		tmpPath = path.Join(ethutil.Config.ExecPath, tmpName)
+		oldPath = path.Join(ethutil.Config.ExecPath, name+".old")
+	)
+
+	// Remove leftovers of an earlier, interrupted migration
+	if err := os.RemoveAll(tmpPath); err != nil {
+		return 0, err
+	}
+
+	src, err := NewLDBDatabase(name)
+	if err != nil {
+		return 0, err
+	}
+
+	dst, err := NewLDBDatabaseWithCodec(tmpName, codecName)
+	if err != nil {
+		src.Close()
+		return 0, err
+	}
+
+	count, err := copyValues(src, dst)
+	src.Close()
+	dst.Close()
+	if err != nil {
+		return count, err
+	}
+
+	if err := os.Rename(srcPath, oldPath); err != nil {
+		return count, err
+	}
+	if err := os.Rename(tmpPath, srcPath); err != nil {
+		return count, err
+	}
+
+	return count, os.RemoveAll(oldPath)
+}
+
+// Copies all values from src to dst. Values are decoded by src and encoded
+// again by dst.
+func copyValues(src, dst ethutil.Database) (int, error) {
+	it := src.NewIterator(nil)
+	defer it.Release()
+
+	var (
+		count int
+		batch = dst.NewBatch()
+	)
+	for it.Next() {
+		if bytes.Equal(it.Key(), codecTagsKey) {
+			continue
+		}
+
+		if err := batch.Put(it.Key(), it.Value()); err != nil {
+			return count, err
+		}
+		count++
+
+		if count%migrateBatchSize == 0 {
+			if err := batch.Write(); err != nil {
+				return count, err
+			}
+			dblogger.Infof("migrated %d values\n", count)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return count, err
+	}
+
+	return count, batch.Write()
+}
@@ -0,0 +1,164 @@
+package ethdb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+var schemaVersionKey = []byte("SchemaVersion")
+
+// A Migration upgrades a database from Version-1 to Version. Migrate queues
+// its changes on the batch and returns the number of entries it changed. The
+// batch is written together with the new schema version, so a migration is
+// either applied completely or not at all.
+type Migration struct {
+	Version     uint64
+	Description string
+	Migrate     func(db ethutil.Database, batch ethutil.Batch) (int, error)
+}
+
+// Result of an applied migration
+type MigrationResult struct {
+	Version     uint64
+	Description string
+	Changed     int
+}
+
+// Migrations ordered by version. migrations[i] upgrades version i to i+1.
+var migrations []Migration
+
+// Reports whether a database holds a chain, see RegisterChainProbe
+var hasChain func(db ethutil.Database) bool
+
+func init() {
+	// Databases written before versioning use the same layout as version 1
+	// and only lack the version record.
+	RegisterMigration(Migration{Version: 1, Description: "record schema version"})
+}
+
+// Adds a migration. Migrations which need to know about the data of other
+// packages are registered by those packages. They must be registered in
+// order of their version, registering one out of order panics.
+func RegisterMigration(m Migration) {
+	if m.Version != uint64(len(migrations))+1 {
+		panic(fmt.Sprintf("ethdb: migration to schema version %d registered out of order", m.Version))
+	}
+
+	migrations = append(migrations, m)
+}
+
+// Sets the function which reports whether a database holds a chain. The
+// chain is stored by core, which registers it along with its migrations.
+// Without it databases lacking a version record are considered empty.
+func RegisterChainProbe(probe func(db ethutil.Database) bool) {
+	hasChain = probe
+}
+
+// Returns the version of the database layout written by this code, which is
+// the version of the last registered migration. Databases with a lower
+// version are upgraded by the registered migrations, databases with a higher
+// version were written by a newer client and are refused.
+func SchemaVersion() uint64 {
+	return uint64(len(migrations))
+}
+
+// Returns the schema version recorded in the database. Databases without a
+// version record that hold a chain are version 0, empty ones are considered
+// current.
+func GetSchemaVersion(db ethutil.Database) uint64 {
+	return getSchemaVersion(db, SchemaVersion())
+}
+
+// Inner function that returns the schema version recorded in the database,
+// current if the database is empty.
+func getSchemaVersion(db ethutil.Database, current uint64) uint64 {
+	data, _ := db.Get(schemaVersionKey)
+	if len(data) == 0 {
+		if hasChain == nil || !hasChain(db) {
+			return current
+		}
+		return 0
+	}
+
+	return ethutil.BigD(data).Uint64()
+}
+
+func putSchemaVersion(batch ethutil.Batch, version uint64) error {
+	return batch.Put(schemaVersionKey, new(big.Int).SetUint64(version).Bytes())
+}
+
+// Returns an error if the database was written by a newer client.
+func CheckSchema(db ethutil.Database) error {
+	return checkSchema(db, SchemaVersion())
+}
+
+// Inner function that returns an error if the database is newer than current.
+func checkSchema(db ethutil.Database, current uint64) error {
+	if version := getSchemaVersion(db, current); version > current {
+		return fmt.Errorf("Database schema version %d is newer than supported version %d", version, current)
+	}
+
+	return nil
+}
+
+// Applies all pending migrations to the database and records the new schema
+// version. Returns the migrations which were applied, in order. If a
+// migration fails the database is left at the last successfully applied
+// version.
+func UpgradeSchema(db ethutil.Database) ([]MigrationResult, error) {
+	return upgradeSchema(db, migrations)
+}
+
+// Inner function that applies the pending migrations of list, which must hold
+// a migration to every version in order, for UpgradeSchema. The last migration
+// of list determines the current version.
+func upgradeSchema(db ethutil.Database, list []Migration) ([]MigrationResult, error) {
+	for i, m := range list {
+		if m.Version != uint64(i)+1 {
+			return nil, fmt.Errorf("migration to schema version %d registered as migration %d", m.Version, i+1)
+		}
+	}
+
+	current := uint64(len(list))
+	if err := checkSchema(db, current); err != nil {
+		return nil, err
+	}
+
+	version := getSchemaVersion(db, current)
+
+	// Fresh databases only need the version record
+	if data, _ := db.Get(schemaVersionKey); len(data) == 0 && version == current {
+		batch := db.NewBatch()
+		if err := putSchemaVersion(batch, current); err != nil {
+			return nil, err
+		}
+		return nil, batch.Write()
+	}
+
+	var results []MigrationResult
+	for _, m := range list[version:] {
+		batch := db.NewBatch()
+
+		var changed int
+		if m.Migrate != nil {
+			var err error
+			if changed, err = m.Migrate(db, batch); err != nil {
+				return results, fmt.Errorf("migration to schema version %d failed: %v", m.Version, err)
+			}
+		}
+
+		if err := putSchemaVersion(batch, m.Version); err != nil {
+			return results, err
+		}
+		if err := batch.Write(); err != nil {
+			return results, fmt.Errorf("migration to schema version %d failed: %v", m.Version, err)
+		}
+
+		dblogger.Infof("upgraded database to schema version %d (%s)\n", m.Version, m.Description)
+		results = append(results, MigrationResult{m.Version, m.Description, changed})
+	}
+
+	return results, nil
+}
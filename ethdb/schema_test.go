@@ -0,0 +1,151 @@
+package ethdb
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+// Number of migrations returned by testMigrations
+const testSchemaVersion = 4
+
+// Returns migrations to every schema version which each write a single entry.
+// The registry can't be used here, most migrations are registered by core,
+// which isn't linked into these tests.
+func testMigrations() []Migration {
+	list := make([]Migration, testSchemaVersion)
+	for i := range list {
+		key := []byte{byte(i)}
+		list[i] = Migration{Version: uint64(i) + 1, Description: "test", Migrate: func(db ethutil.Database, batch ethutil.Batch) (int, error) {
+			return 1, batch.Put(key, key)
+		}}
+	}
+
+	return list
+}
+
+// Registers a chain probe which considers databases with a "LastBlock" entry
+// to hold a chain, like core's. Returns a function restoring the previous one.
+func withChainProbe() func() {
+	prev := hasChain
+	RegisterChainProbe(func(db ethutil.Database) bool {
+		data, _ := db.Get([]byte("LastBlock"))
+		return len(data) > 0
+	})
+
+	return func() { hasChain = prev }
+}
+
+func TestUpgradeSchemaFresh(t *testing.T) {
+	db, _ := NewMemDatabase()
+
+	results, err := upgradeSchema(db, testMigrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no migrations, got %d", len(results))
+	}
+	if data, _ := db.Get(schemaVersionKey); ethutil.BigD(data).Uint64() != testSchemaVersion {
+		t.Errorf("expected schema version %d to be recorded", testSchemaVersion)
+	}
+}
+
+func TestUpgradeSchemaLegacy(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("LastBlock"), []byte{0xc0})
+	defer withChainProbe()()
+
+	if version := GetSchemaVersion(db); version != 0 {
+		t.Fatalf("expected schema version 0, got %d", version)
+	}
+
+	results, err := upgradeSchema(db, testMigrations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != testSchemaVersion {
+		t.Errorf("expected %d migrations, got %d", testSchemaVersion, len(results))
+	}
+	for i, res := range results {
+		if res.Version != uint64(i)+1 || res.Changed != 1 {
+			t.Errorf("migration %d: got version %d, %d changed", i, res.Version, res.Changed)
+		}
+	}
+	if version := GetSchemaVersion(db); version != testSchemaVersion {
+		t.Errorf("expected schema version %d, got %d", testSchemaVersion, version)
+	}
+
+	// Running it again is a no-op
+	if results, _ = upgradeSchema(db, testMigrations()); len(results) != 0 {
+		t.Errorf("expected no migrations, got %d", len(results))
+	}
+}
+
+func TestUpgradeSchemaNewer(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put(schemaVersionKey, big.NewInt(testSchemaVersion).Bytes())
+
+	if _, err := upgradeSchema(db, testMigrations()[:testSchemaVersion-1]); err == nil {
+		t.Error("expected error for newer schema version")
+	}
+	if results, err := upgradeSchema(db, testMigrations()); err != nil || len(results) != 0 {
+		t.Errorf("expected no migrations, got %d (%v)", len(results), err)
+	}
+
+	// The registered migrations determine the supported version
+	db.Put(schemaVersionKey, new(big.Int).SetUint64(SchemaVersion()+1).Bytes())
+	if err := CheckSchema(db); err == nil {
+		t.Error("expected error for newer schema version")
+	}
+}
+
+func TestUpgradeSchemaRegistry(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("LastBlock"), []byte{0xc0})
+	defer withChainProbe()()
+
+	list := testMigrations()
+	list[0], list[1] = list[1], list[0]
+	if _, err := upgradeSchema(db, list); err == nil {
+		t.Error("expected error for migrations out of order")
+	}
+	if version := GetSchemaVersion(db); version != 0 {
+		t.Errorf("expected schema version 0, got %d", version)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic registering migration out of order")
+		}
+	}()
+	RegisterMigration(Migration{Version: SchemaVersion() + 2})
+}
+
+func TestUpgradeSchemaFailure(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("LastBlock"), []byte{0xc0})
+	defer withChainProbe()()
+
+	list := testMigrations()
+	list[2].Migrate = func(db ethutil.Database, batch ethutil.Batch) (int, error) {
+		batch.Put([]byte("partial"), []byte{1})
+		return 0, errors.New("migration failed")
+	}
+
+	results, err := upgradeSchema(db, list)
+	if err == nil {
+		t.Fatal("expected error for failed migration")
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 applied migrations, got %d", len(results))
+	}
+	if version := GetSchemaVersion(db); version != 2 {
+		t.Errorf("expected schema version 2, got %d", version)
+	}
+	if data, _ := db.Get([]byte("partial")); len(data) != 0 {
+		t.Error("changes of failed migration were written")
+	}
+}
@@ -82,6 +82,10 @@ type Ethereum struct {
 
 	Mining bool
 
+	// Download the state of a recent block instead of processing the whole
+	// chain. Only takes effect if the chain is empty on Start.
+	FastSync bool
+
 	listening bool
 
 	RpcServer *rpc.JsonRpcServer
@@ -97,7 +101,10 @@ type Ethereum struct {
 	filters  map[int]*core.Filter
 }
 
-func New(db ethutil.Database, clientIdentity wire.ClientIdentity, keyManager *crypto.KeyManager, caps Caps, usePnp bool) (*Ethereum, error) {
+// Creates a new Ethereum node. The chain starts at the genesis block described
+// by genesis, or at the default genesis block if genesis is nil. An error is
+// returned if db was created with a different genesis block.
+func New(db ethutil.Database, clientIdentity wire.ClientIdentity, keyManager *crypto.KeyManager, caps Caps, usePnp bool, genesis *core.GenesisSpec) (*Ethereum, error) {
 	var err error
 	var nat NAT
 
@@ -112,6 +119,16 @@ func New(db ethutil.Database, clientIdentity wire.ClientIdentity, keyManager *cr
 
 	ethutil.Config.Db = db
 
+	genesisBlock := core.DefaultGenesis()
+	if genesis != nil {
+		if genesisBlock, err = genesis.Block(); err != nil {
+			return nil, err
+		}
+	}
+	if err = core.CheckGenesis(db, genesisBlock); err != nil {
+		return nil, err
+	}
+
 	nonce, _ := ethutil.RandomUint64()
 	ethereum := &Ethereum{
 		shutdownChan:   make(chan bool),
@@ -128,11 +145,16 @@ func New(db ethutil.Database, clientIdentity wire.ClientIdentity, keyManager *cr
 	}
 
 	ethereum.blockPool = NewBlockPool(ethereum)
-	ethereum.blockChain = core.NewChainManager(ethereum.EventMux())
+	ethereum.blockChain = core.NewChainManagerWithGenesis(genesisBlock, ethereum.EventMux())
 	ethereum.txPool = core.NewTxPool(ethereum.blockChain, ethereum, ethereum.EventMux())
 	ethereum.blockManager = core.NewBlockManager(ethereum.txPool, ethereum.blockChain, ethereum.EventMux())
 	ethereum.blockChain.SetProcessor(ethereum.blockManager)
 
+	// Locally submitted transactions are kept in the datadir across restarts
+	if err := ethereum.txPool.OpenJournal(path.Join(ethutil.Config.ExecPath, "transactions.rlp")); err != nil {
+		loggerger.Warnln("failed to open transaction journal:", err)
+	}
+
 	// Start the tx pool
 	ethereum.txPool.Start()
 
@@ -392,6 +414,13 @@ func (s *Ethereum) reapDeadPeerHandler() {
 
 // Start the ethereum
 func (s *Ethereum) Start(seed bool) {
+	if s.FastSync {
+		if s.blockChain.CurrentBlock().Number.Sign() == 0 {
+			s.blockPool.fastSync = newFastSync(s)
+		} else {
+			loggerger.Infoln("Chain not empty, fast sync disabled")
+		}
+	}
 	s.blockPool.Start()
 
 	// Bind to addr and port
@@ -587,7 +616,7 @@ out:
 }
 
 // InstallFilter adds filter for blockchain events.
-// The filter's callbacks will run for matching blocks and messages.
+// The filter's callbacks will run for matching blocks, messages and logs.
 // The filter should not be modified after it has been installed.
 func (self *Ethereum) InstallFilter(filter *core.Filter) (id int) {
 	self.filterMu.Lock()
@@ -614,7 +643,7 @@ func (self *Ethereum) GetFilter(id int) *core.Filter {
 
 func (self *Ethereum) filterLoop() {
 	// Subscribe to events
-	events := self.eventMux.Subscribe(core.NewBlockEvent{}, state.Messages(nil))
+	events := self.eventMux.Subscribe(core.NewBlockEvent{}, state.Messages(nil), state.Logs(nil))
 	for event := range events.Chan() {
 		switch event := event.(type) {
 		case core.NewBlockEvent:
@@ -637,6 +666,18 @@ func (self *Ethereum) filterLoop() {
 				}
 			}
 			self.filterMu.RUnlock()
+
+		case state.Logs:
+			self.filterMu.RLock()
+			for _, filter := range self.filters {
+				if filter.LogsCallback != nil {
+					logs := filter.FilterLogs(event)
+					if len(logs) > 0 {
+						filter.LogsCallback(logs)
+					}
+				}
+			}
+			self.filterMu.RUnlock()
 		}
 	}
 }
@@ -1,12 +1,155 @@
 package ethutil
 
+import (
+	"bytes"
+	"sort"
+)
+
 // Database interface
 type Database interface {
-	Put(key []byte, value []byte)
+	Put(key []byte, value []byte) error
 	Get(key []byte) ([]byte, error)
 	//GetKeys() []*Key
 	Delete(key []byte) error
 	LastKnownTD() []byte
+	NewBatch() Batch
+	NewIterator(r *Range) Iterator
 	Close()
 	Print()
 }
+
+// Batch collects writes and deletes for a Database and applies them all at
+// once when Write is called. Either every change in the batch makes it to
+// the database or none of them do. A batch is not safe for concurrent use.
+//
+// The functions registered with OnWrite are called once Write succeeded, so
+// data queued on the batch can be marked as persisted. They aren't called if
+// Write fails.
+type Batch interface {
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+	Write() error
+	OnWrite(fn func())
+}
+
+// BatchHooks keeps the functions registered with Batch.OnWrite. It is meant
+// to be embedded by Batch implementations, whose Write calls Written after
+// the changes were applied.
+type BatchHooks struct {
+	hooks []func()
+}
+
+func (self *BatchHooks) OnWrite(fn func()) {
+	self.hooks = append(self.hooks, fn)
+}
+
+// Calls the registered functions in the order they were registered and
+// forgets them.
+func (self *BatchHooks) Written() {
+	hooks := self.hooks
+	self.hooks = nil
+
+	for _, fn := range hooks {
+		fn()
+	}
+}
+
+// Iterator walks over the key/value pairs of a Database in ascending key
+// order. The slices returned by Key and Value must not be modified and are
+// only valid until the next call to Next. An iterator must be released
+// after use.
+type Iterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Error() error
+	Release()
+}
+
+// Range selects the keys from Start (inclusive) up to Limit (exclusive).
+// A nil Start begins at the first key and a nil Limit runs to the last key.
+// A nil *Range selects the whole database.
+type Range struct {
+	Start []byte
+	Limit []byte
+}
+
+// Returns the range containing all keys which start with the given prefix.
+func PrefixRange(prefix []byte) *Range {
+	var limit []byte
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if c := prefix[i]; c < 0xff {
+			limit = make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i] = c + 1
+			break
+		}
+	}
+
+	return &Range{Start: prefix, Limit: limit}
+}
+
+// Returns whether the key falls within the range.
+func (r *Range) Contains(key []byte) bool {
+	if r == nil {
+		return true
+	}
+
+	return bytes.Compare(key, r.Start) >= 0 && (r.Limit == nil || bytes.Compare(key, r.Limit) < 0)
+}
+
+// Creates an iterator over the keys of an in-memory key/value map that fall
+// within r. The pairs are collected when the iterator is created, later
+// changes to the map are not reflected.
+func NewMemIterator(db map[string][]byte, r *Range) Iterator {
+	keys := make([]string, 0, len(db))
+	for key := range db {
+		if r.Contains([]byte(key)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	it := &memIterator{keys: keys, values: make([][]byte, len(keys)), pos: -1}
+	for i, key := range keys {
+		it.values[i] = CopyBytes(db[key])
+	}
+
+	return it
+}
+
+type memIterator struct {
+	keys   []string
+	values [][]byte
+	pos    int
+}
+
+func (it *memIterator) Next() bool {
+	if it.pos < len(it.keys) {
+		it.pos++
+	}
+
+	return it.pos < len(it.keys)
+}
+
+func (it *memIterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+
+	return []byte(it.keys[it.pos])
+}
+
+func (it *memIterator) Value() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+
+	return it.values[it.pos]
+}
+
+func (it *memIterator) Error() error { return nil }
+
+func (it *memIterator) Release() {
+	it.keys, it.values = nil, nil
+}
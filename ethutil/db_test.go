@@ -0,0 +1,46 @@
+package ethutil
+
+import (
+	checker "gopkg.in/check.v1"
+)
+
+type DbSuite struct{}
+
+var _ = checker.Suite(&DbSuite{})
+
+func (s *DbSuite) TestPrefixRange(c *checker.C) {
+	r := PrefixRange([]byte("ab"))
+	c.Assert(r.Start, checker.DeepEquals, []byte("ab"))
+	c.Assert(r.Limit, checker.DeepEquals, []byte("ac"))
+
+	r = PrefixRange([]byte{0x01, 0xff})
+	c.Assert(r.Limit, checker.DeepEquals, []byte{0x02})
+
+	r = PrefixRange([]byte{0xff, 0xff})
+	c.Assert(r.Limit, checker.IsNil)
+
+	c.Assert(PrefixRange([]byte("ab")).Contains([]byte("abc")), checker.Equals, true)
+	c.Assert(PrefixRange([]byte("ab")).Contains([]byte("ac")), checker.Equals, false)
+	c.Assert(PrefixRange([]byte("ab")).Contains([]byte("a")), checker.Equals, false)
+}
+
+func (s *DbSuite) TestMemIterator(c *checker.C) {
+	db := map[string][]byte{
+		"b1": []byte("2"),
+		"a":  []byte("1"),
+		"b2": []byte("3"),
+		"c":  []byte("4"),
+	}
+
+	var keys, values []string
+	it := NewMemIterator(db, &Range{Start: []byte("a"), Limit: []byte("c")})
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		values = append(values, string(it.Value()))
+	}
+	it.Release()
+
+	c.Assert(keys, checker.DeepEquals, []string{"a", "b1", "b2"})
+	c.Assert(values, checker.DeepEquals, []string{"1", "2", "3"})
+	c.Assert(it.Next(), checker.Equals, false)
+}
@@ -0,0 +1,162 @@
+package eth
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/trie"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/wire"
+)
+
+const (
+	// Number of blocks below the head of the chain being downloaded at which
+	// the pivot block is picked. The blocks above the pivot are processed.
+	fastSyncPivotDistance = 64
+	// Maximum number of state entries requested from a peer at once, which
+	// is also the most a peer hands out for a single request.
+	nodeDataBatchSize = 384
+	// Time after which the entries requested from a peer are handed out to
+	// other peers again.
+	nodeDataTimeout = 10 * time.Second
+)
+
+// A pending request for state entries
+type nodeDataRequest struct {
+	hashes [][]byte
+	sentAt time.Time
+}
+
+// Downloads the chain without processing it up to a recent pivot block,
+// whose state is downloaded from the peers entry by entry instead. Once the
+// state is complete the pivot becomes the head of the chain and the blocks
+// above it are processed as usual.
+//
+// The blocks below the pivot are stored by ChainManager.InsertFastChain as
+// they arrive; the pivot is picked once fewer than fastSyncPivotDistance
+// blocks remain to be downloaded.
+type fastSync struct {
+	eth *Ethereum
+
+	mu       sync.Mutex
+	pivot    *types.Block
+	sched    *trie.NodeSync
+	requests map[*Peer]*nodeDataRequest
+	done     bool
+}
+
+func newFastSync(eth *Ethereum) *fastSync {
+	return &fastSync{
+		eth:      eth,
+		requests: make(map[*Peer]*nodeDataRequest),
+	}
+}
+
+// Returns whether blocks are still stored without processing them
+func (self *fastSync) Active() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return !self.done
+}
+
+// Returns whether the state of the pivot block is being downloaded
+func (self *fastSync) Syncing() bool {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	return !self.done && self.pivot != nil
+}
+
+// Ends fast sync without a pivot, the remaining blocks are processed as usual
+func (self *fastSync) Stop() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	self.done = true
+}
+
+// Starts downloading the state of the pivot block, which must be stored already
+func (self *fastSync) SetPivot(pivot *types.Block) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	poollogger.Infof("Fast sync pivot #%v (%x), downloading state\n", pivot.Number, pivot.Hash()[:4])
+
+	self.pivot = pivot
+	self.sched = state.NewStateSync(self.eth.db, pivot.State().Root())
+}
+
+// Hands out the missing state entries to the peers without a pending request and re-requests the entries
+// whose request timed out. Once the state is complete the pivot is made the head of the chain. If that
+// fails fast sync goes on without a pivot, until the block pool sets one again.
+func (self *fastSync) Update() {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.done || self.sched == nil {
+		return
+	}
+
+	for peer, req := range self.requests {
+		if time.Since(req.sentAt) > nodeDataTimeout || !peer.statusKnown {
+			self.sched.Requeue(req.hashes)
+			delete(self.requests, peer)
+		}
+	}
+
+	if self.sched.Pending() == 0 {
+		if err := self.eth.ChainManager().CommitFastHead(self.pivot.Hash()); err != nil {
+			// The blocks above the pivot can't be processed without its state. The pool picks the pivot
+			// again, a newer one if more blocks arrived meanwhile, and its state is downloaded anew.
+			poollogger.Infoln("Fast sync pivot commit failed, picking pivot again:", err)
+			self.pivot, self.sched = nil, nil
+			self.requests = make(map[*Peer]*nodeDataRequest)
+
+			return
+		}
+		self.done = true
+
+		return
+	}
+
+	eachPeer(self.eth.peers, func(p *Peer, v *list.Element) {
+		if !p.statusKnown || self.requests[p] != nil {
+			return
+		}
+
+		hashes := self.sched.Missing(nodeDataBatchSize)
+		if len(hashes) == 0 {
+			return
+		}
+
+		self.requests[p] = &nodeDataRequest{hashes, time.Now()}
+		p.QueueMessage(wire.NewMessage(wire.MsgGetNodeDataTy, ethutil.ByteSliceToInterface(hashes)))
+	})
+}
+
+// Stores the state entries received from the peer. Requested entries which the peer didn't deliver are
+// handed out again.
+func (self *fastSync) Deliver(peer *Peer, data [][]byte) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+
+	if self.done || self.sched == nil {
+		return
+	}
+
+	processed, err := self.sched.Process(data)
+	if err != nil {
+		peerlogger.Debugf("(%v) node data: %v\n", peer.conn.RemoteAddr(), err)
+	}
+
+	if req := self.requests[peer]; req != nil {
+		self.sched.Requeue(req.hashes)
+		delete(self.requests, peer)
+	}
+
+	poollogger.Debugf("Stored %d state entries, %d pending\n", processed, self.sched.Pending())
+}
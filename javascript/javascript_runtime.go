@@ -6,6 +6,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/cmd/utils"
@@ -15,6 +16,7 @@ import (
 	"github.com/georzaza/go-ethereum-v0.7.10_official/event"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/state"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ui"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/xeth"
 	"github.com/obscuren/otto"
 )
@@ -29,12 +31,29 @@ type JSRE struct {
 	events event.Subscription
 
 	objectCb map[string][]otto.Value
+
+	// Ids of the filters installed through eth.watch
+	filters []int
+	// Logs of those filters, handed to the main loop which calls back into
+	// the VM
+	logs chan jsLogs
+	quit chan struct{}
+
+	// Guards the VM, which is used by both the caller and the main loop
+	vmMu sync.Mutex
+}
+
+type jsLogs struct {
+	cb   otto.Value
+	logs state.Logs
 }
 
 func (jsre *JSRE) LoadExtFile(path string) {
 	result, err := ioutil.ReadFile(path)
 	if err == nil {
+		jsre.vmMu.Lock()
 		jsre.Vm.Run(result)
+		jsre.vmMu.Unlock()
 	} else {
 		jsrelogger.Infoln("Could not load file:", path)
 	}
@@ -52,6 +71,10 @@ func NewJSRE(ethereum *eth.Ethereum) *JSRE {
 		xeth.NewJSXEth(ethereum),
 		nil,
 		make(map[string][]otto.Value),
+		nil,
+		make(chan jsLogs),
+		make(chan struct{}),
+		sync.Mutex{},
 	}
 
 	// Init the JS lib
@@ -82,6 +105,9 @@ func (self *JSRE) Bind(name string, v interface{}) {
 }
 
 func (self *JSRE) Run(code string) (otto.Value, error) {
+	self.vmMu.Lock()
+	defer self.vmMu.Unlock()
+
 	return self.Vm.Run(code)
 }
 
@@ -95,19 +121,37 @@ func (self *JSRE) Require(file string) error {
 		return err
 	}
 
+	// Only called from eth.require, which already runs on the VM
 	content, _ := ioutil.ReadAll(fh)
-	self.Run("exports = {};(function() {" + string(content) + "})();")
+	self.Vm.Run("exports = {};(function() {" + string(content) + "})();")
 
 	return nil
 }
 
 func (self *JSRE) Stop() {
+	for _, id := range self.filters {
+		self.ethereum.UninstallFilter(id)
+	}
 	self.events.Unsubscribe()
+	close(self.quit)
 	jsrelogger.Infoln("stopped")
 }
 
 func (self *JSRE) mainLoop() {
-	for _ = range self.events.Chan() {
+	for {
+		select {
+		case _, ok := <-self.events.Chan():
+			if !ok {
+				return
+			}
+		case ev := <-self.logs:
+			self.vmMu.Lock()
+			v, _ := self.Vm.ToValue(xeth.ToJSLogs(ev.logs).Interface())
+			ev.cb.Call(ev.cb, v)
+			self.vmMu.Unlock()
+		case <-self.quit:
+			return
+		}
 	}
 }
 
@@ -170,7 +214,15 @@ func (self *JSRE) startMining(call otto.FunctionCall) otto.Value {
 }
 
 // eth.watch
+//
+// Either watch(filterOptions, callback), which calls back with the logs
+// matching the options (see ui.NewFilterFromMap) and returns the filter id,
+// or watch(address, [storageAddress,] callback).
 func (self *JSRE) watch(call otto.FunctionCall) otto.Value {
+	if call.Argument(0).IsObject() {
+		return self.watchLogs(call)
+	}
+
 	addr, _ := call.Argument(0).ToString()
 	var storageAddr string
 	var cb otto.Value
@@ -198,6 +250,31 @@ func (self *JSRE) watch(call otto.FunctionCall) otto.Value {
 	return otto.UndefinedValue()
 }
 
+func (self *JSRE) watchLogs(call otto.FunctionCall) otto.Value {
+	options, _ := call.Argument(0).Export()
+	object, ok := options.(map[string]interface{})
+	if !ok {
+		fmt.Println("invalid filter options for watch")
+
+		return otto.UndefinedValue()
+	}
+	cb := call.Argument(1)
+
+	filter := ui.NewFilterFromMap(object, self.ethereum)
+	filter.LogsCallback = func(logs state.Logs) {
+		select {
+		case self.logs <- jsLogs{cb, logs}:
+		case <-self.quit:
+		}
+	}
+	id := self.ethereum.InstallFilter(filter)
+	self.filters = append(self.filters, id)
+
+	v, _ := self.Vm.ToValue(id)
+
+	return v
+}
+
 func (self *JSRE) addPeer(call otto.FunctionCall) otto.Value {
 	host, err := call.Argument(0).ToString()
 	if err != nil {
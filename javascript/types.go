@@ -92,6 +92,14 @@ func (self *JSEthereum) Peers() otto.Value {
 	return self.toVal(self.JSXEth.Peers())
 }
 
+func (self *JSEthereum) BadBlocks() otto.Value {
+	return self.toVal(self.JSXEth.BadBlocks())
+}
+
+func (self *JSEthereum) TxPoolStatus() otto.Value {
+	return self.toVal(self.JSXEth.TxPoolStatus())
+}
+
 func (self *JSEthereum) Transact(key, recipient, valueStr, gasStr, gasPriceStr, dataStr string) otto.Value {
 	r, err := self.JSXEth.Transact(key, recipient, valueStr, gasStr, gasPriceStr, dataStr)
 	if err != nil {
@@ -140,3 +148,11 @@ func (self *JSEthereum) Messages(object map[string]interface{}) otto.Value {
 
 	return v
 }
+
+func (self *JSEthereum) Logs(object map[string]interface{}) otto.Value {
+	filter := ui.NewFilterFromMap(object, self.ethereum)
+
+	v, _ := self.vm.ToValue(xeth.ToJSLogs(filter.FindLogs()).Interface())
+
+	return v
+}
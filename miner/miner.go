@@ -25,12 +25,10 @@ package miner
 
 import (
 	"math/big"
-	"sort"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/pow"
-	"github.com/georzaza/go-ethereum-v0.7.10_official/pow/ezp"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
@@ -58,10 +56,9 @@ type Miner struct {
 	events event.Subscription
 
 	uncles    types.Blocks
-	localTxs  map[int]*LocalTx
+	localTxs  map[int]*types.Transaction
 	localTxId int
 
-	pow       pow.PoW
 	quitCh    chan struct{}
 	powQuitCh chan struct{}
 
@@ -76,31 +73,66 @@ func New(coinbase []byte, eth *eth.Ethereum) *Miner {
 	return &Miner{
 		eth:                 eth,
 		powQuitCh:           make(chan struct{}),
-		pow:                 ezp.New(),
 		mining:              false,
-		localTxs:            make(map[int]*LocalTx),
+		localTxs:            make(map[int]*types.Transaction),
 		MinAcceptedGasPrice: big.NewInt(10000000000000),
 		Coinbase:            coinbase,
 	}
 }
 
+// Returns the proof of work the miner seals blocks with, nil if the consensus
+// engine of the block manager doesn't use one.
 func (self *Miner) GetPow() pow.PoW {
-	return self.pow
+	if engine, ok := self.eth.BlockManager().Engine.(*core.PowEngine); ok {
+		return engine.PoW
+	}
+
+	return nil
 }
 
-func (self *Miner) AddLocalTx(tx *LocalTx) int {
-	minerlogger.Infof("Added local tx (%x %v / %v)\n", tx.To[0:4], tx.GasPrice, tx.Value)
+// Returns the hash rate of the proof of work, 0 if the consensus engine
+// doesn't use one.
+func (self *Miner) HashRate() int64 {
+	if pow := self.GetPow(); pow != nil {
+		return pow.GetHashrate()
+	}
+
+	return 0
+}
+
+// Signs the transaction with the key of the node and adds it to the transaction
+// pool as a local transaction, which is journaled and included by the miner
+// regardless of MinAcceptedGasPrice. Returns the id of the transaction, or 0 if
+// the pool rejected it.
+func (self *Miner) AddLocalTx(ltx *LocalTx) int {
+	// XXX This has to change. Coinbase is, for new, same as key.
+	key := self.eth.KeyManager()
+	state := self.eth.ChainManager().TransState()
+
+	tx := types.NewTransactionMessage(ltx.To, ethutil.Big(ltx.Value), ethutil.Big(ltx.Gas), ethutil.Big(ltx.GasPrice), ltx.Data)
+	tx.SetNonce(state.GetNonce(key.Address()))
+	tx.Sign(key.PrivateKey())
+
+	if err := self.eth.TxPool().AddLocal(tx); err != nil {
+		minerlogger.Infoln("Local tx rejected:", err)
+		return 0
+	}
+	state.SetNonce(key.Address(), tx.Nonce()+1)
+
+	minerlogger.Infof("Added local tx (%x %v / %v)\n", tx.Hash()[0:4], ltx.GasPrice, ltx.Value)
 
 	self.localTxId++
 	self.localTxs[self.localTxId] = tx
-	self.eth.EventMux().Post(tx)
+	self.eth.EventMux().Post(ltx)
 
 	return self.localTxId
 }
 
+// Removes the local transaction with the given id from the transaction pool
 func (self *Miner) RemoveLocalTx(id int) {
 	if tx := self.localTxs[id]; tx != nil {
-		minerlogger.Infof("Removed local tx (%x %v / %v)\n", tx.To[0:4], tx.GasPrice, tx.Value)
+		minerlogger.Infof("Removed local tx (%x %v / %v)\n", tx.Hash()[0:4], tx.GasPrice(), tx.Value())
+		self.eth.TxPool().RemoveSet(types.Transactions{tx})
 	}
 	self.eth.EventMux().Post(&LocalTx{})
 
@@ -176,6 +208,7 @@ func (self *Miner) reset() {
 func (self *Miner) mine() {
 	var (
 		blockManager = self.eth.BlockManager()
+		engine       = blockManager.Engine
 		chainMan     = self.eth.ChainManager()
 		block        = chainMan.NewBlock(self.Coinbase)
 	)
@@ -186,6 +219,8 @@ func (self *Miner) mine() {
 	}
 
 	parent := chainMan.GetBlock(block.PrevHash)
+	engine.Prepare(block, parent)
+
 	coinbase := block.State().GetOrNewStateObject(block.Coinbase)
 	coinbase.SetGasPool(block.CalcGasLimit(parent))
 
@@ -203,14 +238,14 @@ func (self *Miner) mine() {
 	block.SetReceipts(receipts)
 
 	// Accumulate the rewards included for this block
-	blockManager.AccumelateRewards(block.State(), block, parent)
+	engine.Finalize(chainMan, block.State(), block, parent)
 
 	block.State().Update(ethutil.Big0)
 
 	minerlogger.Infof("Mining on block. Includes %v transactions", len(transactions))
 
-	// Find a valid nonce
-	nonce := self.pow.Search(block, self.powQuitCh)
+	// Seal the block, for proof of work this means finding a valid nonce
+	nonce := engine.Seal(block, self.powQuitCh)
 	if nonce != nil {
 		block.Nonce = nonce
 		err := chainMan.InsertChain(types.Blocks{block})
@@ -228,34 +263,28 @@ func (self *Miner) mine() {
 }
 
 func (self *Miner) finiliseTxs() types.Transactions {
-	// Sort the transactions by nonce in case of odd network propagation
-	actualSize := len(self.localTxs) // See copy below
-	txs := make(types.Transactions, actualSize+self.eth.TxPool().Size())
+	txs := make(types.Transactions, 0, self.eth.TxPool().Size())
 
-	state := self.eth.ChainManager().TransState()
-	// XXX This has to change. Coinbase is, for new, same as key.
-	key := self.eth.KeyManager()
-	for i, ltx := range self.localTxs {
-		tx := types.NewTransactionMessage(ltx.To, ethutil.Big(ltx.Value), ethutil.Big(ltx.Gas), ethutil.Big(ltx.GasPrice), ltx.Data)
-		tx.SetNonce(state.GetNonce(self.Coinbase))
-		state.SetNonce(self.Coinbase, tx.Nonce()+1)
-
-		tx.Sign(key.PrivateKey())
-
-		txs[i] = tx
+	// Local transactions are in the pool as well, but they are included
+	// whatever their gas price
+	local := make(map[string]bool)
+	for _, tx := range self.localTxs {
+		local[string(tx.Hash())] = true
 	}
 
-	// Faster than append
-	for _, tx := range self.eth.TxPool().CurrentTransactions() {
-		if tx.GasPrice().Cmp(self.MinAcceptedGasPrice) >= 0 {
-			txs[actualSize] = tx
-			actualSize++
+	// The pool's pending transactions are ordered by gas price and each
+	// sender's by nonce. Once a transaction is skipped the following ones of
+	// its sender can't be executed either.
+	skipped := make(map[string]bool)
+	for _, tx := range self.eth.TxPool().PendingTransactions() {
+		from := string(tx.From())
+		if skipped[from] || (!local[string(tx.Hash())] && tx.GasPrice().Cmp(self.MinAcceptedGasPrice) < 0) {
+			skipped[from] = true
+			continue
 		}
-	}
 
-	newTransactions := make(types.Transactions, actualSize)
-	copy(newTransactions, txs[:actualSize])
-	sort.Sort(types.TxByNonce{newTransactions})
+		txs = append(txs, tx)
+	}
 
-	return newTransactions
+	return txs
 }
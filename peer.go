@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/logger"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/wire"
@@ -324,7 +325,7 @@ out:
 		case msg := <-p.outputQueue:
 			if !p.statusKnown {
 				switch msg.Type {
-				case wire.MsgTxTy, wire.MsgGetBlockHashesTy, wire.MsgBlockHashesTy, wire.MsgGetBlocksTy, wire.MsgBlockTy:
+				case wire.MsgTxTy, wire.MsgGetBlockHashesTy, wire.MsgBlockHashesTy, wire.MsgGetBlocksTy, wire.MsgBlockTy, wire.MsgGetNodeDataTy, wire.MsgNodeDataTy:
 					break skip
 				}
 			}
@@ -489,6 +490,37 @@ func (p *Peer) HandleInbound() {
 
 					p.QueueMessage(wire.NewMessage(wire.MsgBlockTy, blocks))
 
+				case wire.MsgGetNodeDataTy:
+					// Hand out state trie nodes and code, limited to nodeDataBatchSize entries
+					max := int(math.Min(float64(msg.Data.Len()), nodeDataBatchSize))
+					var data []interface{}
+
+					for i := 0; i < max; i++ {
+						// Only content addressed entries (trie nodes and code) are handed out, never blocks or
+						// the bookkeeping stored under other keys
+						hash := msg.Data.Get(i).Bytes()
+						if len(hash) != 32 {
+							continue
+						}
+						if entry, _ := p.ethereum.db.Get(hash); len(entry) > 0 && bytes.Equal(crypto.Sha3(entry), hash) {
+							data = append(data, entry)
+						}
+					}
+
+					p.QueueMessage(wire.NewMessage(wire.MsgNodeDataTy, data))
+
+				case wire.MsgNodeDataTy:
+					if fast := p.ethereum.blockPool.fastSync; fast != nil {
+						var data [][]byte
+
+						it := msg.Data.NewIterator()
+						for it.Next() {
+							data = append(data, it.Value().Bytes())
+						}
+
+						fast.Deliver(p, data)
+					}
+
 				case wire.MsgBlockHashesTy:
 					p.catchingUp = true
 
@@ -2,7 +2,7 @@ package ptrie
 
 type Backend interface {
 	Get([]byte) ([]byte, error)
-	Put([]byte, []byte)
+	Put([]byte, []byte) error
 }
 
 type Cache struct {
@@ -27,14 +27,18 @@ func (self *Cache) Put(key []byte, data []byte) {
 	self.store[string(key)] = data
 }
 
-func (self *Cache) Flush() {
+func (self *Cache) Flush() error {
 	for k, v := range self.store {
-		self.backend.Put([]byte(k), v)
+		if err := self.backend.Put([]byte(k), v); err != nil {
+			return err
+		}
 	}
 
 	// This will eventually grow too large. We'd could
 	// do a make limit on storage and push out not-so-popular nodes.
 	//self.Reset()
+
+	return nil
 }
 
 func (self *Cache) Reset() {
@@ -72,11 +72,11 @@ func (self *Trie) Hash() []byte {
 
 	return hash
 }
-func (self *Trie) Commit() {
+func (self *Trie) Commit() error {
 	// Hash first
 	self.Hash()
 
-	self.cache.Flush()
+	return self.cache.Flush()
 }
 
 // Reset should only be called if the trie has been hashed
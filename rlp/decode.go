@@ -516,6 +516,66 @@ func (s *Stream) Bytes() ([]byte, error) {
 	}
 }
 
+// Raw reads a raw encoded value including RLP type information.
+func (s *Stream) Raw() ([]byte, error) {
+	kind, size, err := s.Kind()
+	if err != nil {
+		return nil, err
+	}
+	if kind == Byte {
+		s.kind = -1 // rearm Kind
+		return []byte{s.byteval}, nil
+	}
+	// the original header has already been read and is no longer
+	// available. read content and put a new header in front of it.
+	start := headsize(size)
+	buf := make([]byte, uint64(start)+size)
+	if err := s.readFull(buf[start:]); err != nil {
+		return nil, err
+	}
+	if kind == String {
+		puthead(buf, 0x80, 0xB7, size)
+	} else {
+		puthead(buf, 0xC0, 0xF7, size)
+	}
+	return buf, nil
+}
+
+// headsize returns the size of a string or list header
+// for a value of the given size.
+func headsize(size uint64) int {
+	if size < 56 {
+		return 1
+	}
+	return 1 + intsize(size)
+}
+
+// intsize returns the number of bytes needed to encode i
+// in big endian order without leading zero bytes.
+func intsize(i uint64) (size int) {
+	for size = 1; ; size++ {
+		if i >>= 8; i == 0 {
+			return size
+		}
+	}
+}
+
+// puthead writes a string or list header for a value of
+// the given size to buf, which must be at least headsize(size)
+// bytes long.
+func puthead(buf []byte, smalltag, largetag byte, size uint64) {
+	if size < 56 {
+		buf[0] = smalltag + byte(size)
+		return
+	}
+	sizesize := intsize(size)
+	buf[0] = largetag + byte(sizesize)
+	for i := sizesize; i > 0; i-- {
+		buf[i] = byte(size)
+		size >>= 8
+	}
+}
+
 var errUintOverflow = errors.New("rlp: uint overflow")
 
 // Uint reads an RLP string of up to 8 bytes and returns its contents
@@ -7,6 +7,7 @@ import (
 	"io"
 	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
@@ -150,6 +151,33 @@ func TestStreamList(t *testing.T) {
 	}
 }
 
+func TestStreamRaw(t *testing.T) {
+	inputs := []string{
+		"01",
+		"80",
+		"83646F67",
+		"C0",
+		"C80102030405060708",
+		"F83C" + strings.Repeat("83646F67", 15),
+		"B838" + strings.Repeat("61", 56),
+	}
+	for i, input := range inputs {
+		s := NewStream(bytes.NewReader(unhex(input + "01")))
+
+		raw, err := s.Raw()
+		if err != nil {
+			t.Errorf("test %d: Raw error: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(raw, unhex(input)) {
+			t.Errorf("test %d: Raw mismatch\ngot:  %x\nwant: %s", i, raw, input)
+		}
+		if v, err := s.Uint(); v != 1 || err != nil {
+			t.Errorf("test %d: value after Raw returned (%d, %v), expected (1, nil)", i, v, err)
+		}
+	}
+}
+
 func TestDecodeErrors(t *testing.T) {
 	r := bytes.NewReader(nil)
 
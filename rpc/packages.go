@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"strings"
 
+	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/xeth"
 )
@@ -29,6 +30,37 @@ type GetBlockArgs struct {
 type ErrorResponse struct {
 	Error     bool   `json:"error"`
 	ErrorText string `json:"errorText"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// Creates an error response for a transaction the pool rejected. reason tells
+// which check failed, see txRejectReason.
+func NewRejectResponse(reason, msg string) error {
+	e := ErrorResponse{Error: true, ErrorText: msg, Reason: reason}
+	res, err := json.Marshal(e)
+	if err != nil {
+		// This should never happen
+		panic("Creating json error response failed, help")
+	}
+	return errors.New(string(res))
+}
+
+// Returns the reason the transaction pool rejected a transaction with err
+func txRejectReason(err error) string {
+	switch {
+	case core.IsNonceErr(err):
+		return "nonce"
+	case core.IsInsufficientFundsErr(err):
+		return "insufficientFunds"
+	case core.IsIntrinsicGasErr(err):
+		return "intrinsicGas"
+	case core.IsGasLimitErr(err):
+		return "gasLimit"
+	case core.IsOversizedDataErr(err):
+		return "oversizedData"
+	}
+
+	return "invalid"
 }
 
 type JsonResponse interface {
@@ -161,7 +193,10 @@ func (p *EthereumApi) PushTx(args *PushTxArgs, reply *string) error {
 	if err != nil {
 		return err
 	}
-	result, _ := p.pipe.PushTx(args.Tx)
+	result, err := p.pipe.PushTx(args.Tx)
+	if err != nil {
+		return NewRejectResponse(txRejectReason(err), err.Error())
+	}
 	*reply = NewSuccessRes(result)
 	return nil
 }
@@ -274,6 +309,49 @@ func (p *EthereumApi) GetTxCountAt(args *GetTxCountArgs, reply *string) error {
 	return nil
 }
 
+type GetTransactionArgs struct {
+	Hash string
+}
+
+func (a *GetTransactionArgs) requirements() error {
+	if a.Hash == "" {
+		return NewErrorResponse("GetTransaction requires a 'hash' value as argument")
+	}
+	return nil
+}
+
+type GetTransactionRes struct {
+	Transaction *xeth.JSTransaction `json:"transaction"`
+	Receipt     *xeth.JSTxReceipt   `json:"receipt"`
+}
+
+func (p *EthereumApi) GetTransaction(args *GetTransactionArgs, reply *string) error {
+	err := args.requirements()
+	if err != nil {
+		return err
+	}
+	tx, receipt := p.pipe.TransactionReceipt(args.Hash)
+	if tx == nil {
+		return NewErrorResponse("GetTransaction: transaction not found in the chain")
+	}
+	*reply = NewSuccessRes(GetTransactionRes{Transaction: tx, Receipt: receipt})
+	return nil
+}
+
+type GetBadBlocksRes struct {
+	BadBlocks []*xeth.JSBadBlock `json:"badBlocks"`
+}
+
+func (p *EthereumApi) GetBadBlocks(args *interface{}, reply *string) error {
+	*reply = NewSuccessRes(GetBadBlocksRes{BadBlocks: p.pipe.BadBlocks()})
+	return nil
+}
+
+func (p *EthereumApi) GetTxPoolStatus(args *interface{}, reply *string) error {
+	*reply = NewSuccessRes(p.pipe.TxPoolStatus())
+	return nil
+}
+
 type GetBalanceArgs struct {
 	Address string
 }
@@ -3,6 +3,8 @@ package state
 import (
 	"fmt"
 	"math/big"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
 )
 
 // Object manifest
@@ -31,6 +33,20 @@ func (self *Manifest) AddMessage(msg *Message) *Message {
 }
 
 type Messages []*Message
+
+func (self Messages) RlpData() interface{} {
+	data := make([]interface{}, len(self))
+	for i, msg := range self {
+		data[i] = msg.RlpData()
+	}
+
+	return data
+}
+
+func (self Messages) RlpEncode() []byte {
+	return ethutil.Encode(self.RlpData())
+}
+
 type Message struct {
 	To, From  []byte
 	Input     []byte
@@ -46,6 +62,33 @@ type Message struct {
 	ChangedAddresses [][]byte
 }
 
+func NewMessageFromValue(decoder *ethutil.Value) *Message {
+	msg := &Message{
+		To:        decoder.Get(0).Bytes(),
+		From:      decoder.Get(1).Bytes(),
+		Input:     decoder.Get(2).Bytes(),
+		Output:    decoder.Get(3).Bytes(),
+		Path:      int(decoder.Get(4).Uint()),
+		Origin:    decoder.Get(5).Bytes(),
+		Timestamp: int64(decoder.Get(6).Uint()),
+		Coinbase:  decoder.Get(7).Bytes(),
+		Block:     decoder.Get(8).Bytes(),
+		Number:    decoder.Get(9).BigInt(),
+		Value:     decoder.Get(10).BigInt(),
+	}
+
+	it := decoder.Get(11).NewIterator()
+	for it.Next() {
+		msg.ChangedAddresses = append(msg.ChangedAddresses, it.Value().Bytes())
+	}
+
+	return msg
+}
+
+func (self *Message) RlpData() interface{} {
+	return []interface{}{self.To, self.From, self.Input, self.Output, self.Path, self.Origin, self.Timestamp, self.Coinbase, self.Block, self.Number, self.Value, ethutil.ByteSliceToInterface(self.ChangedAddresses)}
+}
+
 func (self *Message) AddStorageChange(addr []byte) {
 	self.ChangedAddresses = append(self.ChangedAddresses, addr)
 }
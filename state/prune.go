@@ -0,0 +1,49 @@
+package state
+
+import (
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/trie"
+)
+
+// Adds the database keys needed by the state with the given root to marked:
+// the nodes of the state trie, the nodes of every account's storage trie and
+// the accounts' code. Sub tries which are already marked are not walked again,
+// so marking several states which share most of their nodes stays cheap.
+func MarkNodes(db ethutil.Database, root []byte, marked map[string]bool) error {
+	var (
+		storageRoots []interface{}
+		codeHashes   [][]byte
+	)
+
+	mark := func(hash []byte) bool {
+		if marked[string(hash)] {
+			return false
+		}
+		marked[string(hash)] = true
+
+		return true
+	}
+
+	err := trie.Walk(db, root, mark, func(value *ethutil.Value) {
+		account := ethutil.NewValueFromBytes(value.Bytes())
+		storageRoots = append(storageRoots, account.Get(2).Raw())
+		codeHashes = append(codeHashes, account.Get(3).Bytes())
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, storageRoot := range storageRoots {
+		if err := trie.Walk(db, storageRoot, mark, nil); err != nil {
+			return err
+		}
+	}
+
+	for _, codeHash := range codeHashes {
+		if len(codeHash) > 0 {
+			marked[string(codeHash)] = true
+		}
+	}
+
+	return nil
+}
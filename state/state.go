@@ -262,19 +262,48 @@ func (s *StateDB) Reset() {
 }
 
 // Syncs the trie and all siblings
-func (s *StateDB) Sync() {
+func (s *StateDB) Sync() error {
 	// Sync all nested states
 	for _, stateObject := range s.stateObjects {
 		if stateObject.State == nil {
 			continue
 		}
 
-		stateObject.State.Sync()
+		if err := stateObject.State.Sync(); err != nil {
+			return err
+		}
 	}
 
-	s.Trie.Sync()
+	if err := s.Trie.Sync(); err != nil {
+		return err
+	}
 
 	s.Empty()
+
+	return nil
+}
+
+// Same as Sync but all trie nodes are added to the given batch so the
+// state can be committed together with other data. The cached state objects
+// are kept until the batch was written, so a failed write can be retried.
+func (s *StateDB) SyncTo(batch ethutil.Batch) error {
+	for _, stateObject := range s.stateObjects {
+		if stateObject.State == nil {
+			continue
+		}
+
+		if err := stateObject.State.SyncTo(batch); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Trie.SyncTo(batch); err != nil {
+		return err
+	}
+
+	batch.OnWrite(s.Empty)
+
+	return nil
 }
 
 func (self *StateDB) Empty() {
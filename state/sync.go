@@ -0,0 +1,30 @@
+package state
+
+import (
+	"bytes"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/trie"
+)
+
+// Hash of the code of accounts without code, which is never stored
+var emptyCodeHash = crypto.Sha3(nil)
+
+// Returns a NodeSync which downloads the state with the given root into db:
+// the nodes of the state trie, the nodes of every account's storage trie and
+// the accounts' code. The storage trie and code of an account are scheduled
+// as soon as the state trie node holding the account is stored.
+func NewStateSync(db ethutil.Database, root []byte) *trie.NodeSync {
+	sync := trie.NewNodeSync(db)
+	sync.AddSubTrie(root, func(value *ethutil.Value) {
+		account := ethutil.NewValueFromBytes(value.Bytes())
+		sync.AddSubTrie(account.Get(2).Bytes(), nil)
+
+		if codeHash := account.Get(3).Bytes(); len(codeHash) > 0 && !bytes.Equal(codeHash, emptyCodeHash) {
+			sync.AddRawEntry(codeHash)
+		}
+	})
+
+	return sync
+}
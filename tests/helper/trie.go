@@ -1,6 +1,9 @@
 package helper
 
-import "github.com/georzaza/go-ethereum-v0.7.10_official/trie"
+import (
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/trie"
+)
 
 type MemDatabase struct {
 	db map[string][]byte
@@ -10,8 +13,9 @@ func NewMemDatabase() (*MemDatabase, error) {
 	db := &MemDatabase{db: make(map[string][]byte)}
 	return db, nil
 }
-func (db *MemDatabase) Put(key []byte, value []byte) {
+func (db *MemDatabase) Put(key []byte, value []byte) error {
 	db.db[string(key)] = value
+	return nil
 }
 func (db *MemDatabase) Get(key []byte) ([]byte, error) {
 	return db.db[string(key)], nil
@@ -20,9 +24,35 @@ func (db *MemDatabase) Delete(key []byte) error {
 	delete(db.db, string(key))
 	return nil
 }
+func (db *MemDatabase) NewBatch() ethutil.Batch {
+	return &memBatch{db: db, writes: make(map[string][]byte)}
+}
 func (db *MemDatabase) Print()              {}
 func (db *MemDatabase) Close()              {}
 func (db *MemDatabase) LastKnownTD() []byte { return nil }
+func (db *MemDatabase) NewIterator(r *ethutil.Range) ethutil.Iterator {
+	return ethutil.NewMemIterator(db.db, r)
+}
+
+type memBatch struct {
+	ethutil.BatchHooks
+	db     *MemDatabase
+	writes map[string][]byte
+}
+
+func (b *memBatch) Put(key, value []byte) error { b.writes[string(key)] = value; return nil }
+func (b *memBatch) Delete(key []byte) error     { b.writes[string(key)] = nil; return nil }
+func (b *memBatch) Write() error {
+	for k, v := range b.writes {
+		if v == nil {
+			delete(b.db.db, k)
+		} else {
+			b.db.db[k] = v
+		}
+	}
+	b.Written()
+	return nil
+}
 
 func NewTrie() *trie.Trie {
 	db, _ := NewMemDatabase()
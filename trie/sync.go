@@ -0,0 +1,182 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+// Returned by NodeSync.Process for data which doesn't hash to any of the
+// requested entries.
+var ErrNotRequested = errors.New("trie: node data not requested")
+
+// Called with every value of a trie being downloaded by a NodeSync. Allows
+// the caller to schedule further entries the value refers to, such as the
+// storage tries and code of accounts.
+type SyncLeafCallback func(value *ethutil.Value)
+
+// A request for a single database entry which is stored by the hash of its
+// value. raw entries (e.g. code) are not trie nodes and have no children.
+type syncRequest struct {
+	hash   []byte
+	raw    bool
+	onLeaf SyncLeafCallback
+}
+
+// Downloads tries node by node, by handing out the hashes of missing nodes
+// (see Missing) and storing the data received for them (see Process). The
+// children of a node are scheduled once the node itself is stored, until
+// the tries are complete.
+//
+// Nodes which are already in the database are walked instead of requested,
+// so a download which was interrupted continues where it left off.
+//
+// A NodeSync is not safe for concurrent use.
+type NodeSync struct {
+	db      ethutil.Database
+	pending map[string]*syncRequest
+	queue   [][]byte
+}
+
+func NewNodeSync(db ethutil.Database) *NodeSync {
+	return &NodeSync{
+		db:      db,
+		pending: make(map[string]*syncRequest),
+	}
+}
+
+// Schedules the trie with the given root for download. onLeaf (may be nil)
+// is called with every value of the trie once the node holding it is stored.
+func (self *NodeSync) AddSubTrie(root []byte, onLeaf SyncLeafCallback) {
+	self.ref(ethutil.NewValue(root), onLeaf)
+}
+
+// Schedules the raw entry with the given hash (e.g. the code of an account)
+// for download.
+func (self *NodeSync) AddRawEntry(hash []byte) {
+	self.schedule(&syncRequest{hash: hash, raw: true})
+}
+
+// Returns the hashes of up to max (zero for all) missing entries which
+// weren't handed out before. Hashes which are still pending after their
+// request failed can be handed out again with Requeue.
+func (self *NodeSync) Missing(max int) [][]byte {
+	var hashes [][]byte
+	for len(self.queue) > 0 && (max <= 0 || len(hashes) < max) {
+		hash := self.queue[0]
+		self.queue = self.queue[1:]
+
+		// Entries may have been delivered before they were handed out
+		if self.pending[string(hash)] != nil {
+			hashes = append(hashes, hash)
+		}
+	}
+
+	return hashes
+}
+
+// Hands out the given hashes again, ignoring the ones which are no longer
+// pending.
+func (self *NodeSync) Requeue(hashes [][]byte) {
+	for _, hash := range hashes {
+		if self.pending[string(hash)] != nil {
+			self.queue = append(self.queue, hash)
+		}
+	}
+}
+
+// Stores the given entries, each of which is verified to hash to a pending
+// request, and schedules their children. Returns the number of stored
+// entries and ErrNotRequested if any of the data wasn't requested; the
+// remaining data is processed regardless.
+func (self *NodeSync) Process(data [][]byte) (int, error) {
+	var (
+		processed int
+		err       error
+	)
+	for _, blob := range data {
+		hash := crypto.Sha3(blob)
+		req := self.pending[string(hash)]
+		if req == nil {
+			err = ErrNotRequested
+			continue
+		}
+
+		if e := self.db.Put(hash, blob); e != nil {
+			return processed, e
+		}
+		delete(self.pending, string(hash))
+		processed++
+
+		if !req.raw {
+			self.expand(ethutil.NewValueFromBytes(blob), req.onLeaf)
+		}
+	}
+
+	return processed, err
+}
+
+// Returns the number of entries which are requested but not stored yet
+func (self *NodeSync) Pending() int {
+	return len(self.pending)
+}
+
+// Schedules the request unless its entry is pending already. Entries which
+// are in the database are expanded right away.
+func (self *NodeSync) schedule(req *syncRequest) {
+	if self.pending[string(req.hash)] != nil {
+		return
+	}
+
+	if data, _ := self.db.Get(req.hash); len(data) > 0 {
+		if !req.raw {
+			self.expand(ethutil.NewValueFromBytes(data), req.onLeaf)
+		}
+		return
+	}
+
+	self.pending[string(req.hash)] = req
+	self.queue = append(self.queue, req.hash)
+}
+
+// Resolves a reference to a child node the same way as walk does: embedded
+// nodes are expanded, hashes scheduled.
+func (self *NodeSync) ref(node *ethutil.Value, onLeaf SyncLeafCallback) {
+	if !node.Get(0).IsNil() {
+		self.expand(node, onLeaf)
+		return
+	}
+
+	ref := node.Bytes()
+	if len(ref) == 0 || bytes.Equal(ref, emptyRoot) {
+		return
+	}
+
+	if len(ref) < 32 {
+		self.expand(ethutil.NewValueFromBytes(ref), onLeaf)
+	} else {
+		self.schedule(&syncRequest{hash: ref, onLeaf: onLeaf})
+	}
+}
+
+// Schedules the children of the node and reports its values to onLeaf
+func (self *NodeSync) expand(node *ethutil.Value, onLeaf SyncLeafCallback) {
+	switch getType(node) {
+	case LeafNode:
+		if onLeaf != nil {
+			onLeaf(node.Get(1))
+		}
+	case ExtNode:
+		self.ref(node.Get(1), onLeaf)
+	case BranchNode:
+		for i := 0; i < 16; i++ {
+			self.ref(node.Get(i), onLeaf)
+		}
+
+		if value := node.Get(16); value.Len() > 0 && onLeaf != nil {
+			onLeaf(value)
+		}
+	}
+}
@@ -105,25 +105,60 @@ func (cache *Cache) Delete(key []byte) {
 	cache.db.Delete(key)
 }
 
-func (cache *Cache) Commit() {
+// Writes all dirty nodes to the database in a single batch.
+func (cache *Cache) Commit() error {
 	// Don't try to commit if it isn't dirty
 	if !cache.IsDirty {
-		return
+		return nil
+	}
+
+	batch := cache.db.NewBatch()
+	if err := cache.CommitTo(batch); err != nil {
+		return err
+	}
+
+	return batch.Write()
+}
+
+// Queues all dirty nodes on the given batch. The nodes are only persisted
+// once the caller writes the batch, so they stay dirty until the write
+// succeeded: if it fails, committing again queues them again.
+func (cache *Cache) CommitTo(batch ethutil.Batch) error {
+	if !cache.IsDirty {
+		return nil
 	}
 
+	var queued []*Node
 	for key, node := range cache.nodes {
 		if node.Dirty {
-			cache.db.Put([]byte(key), node.Value.Encode())
-			node.Dirty = false
+			if err := batch.Put([]byte(key), node.Value.Encode()); err != nil {
+				return err
+			}
+			queued = append(queued, node)
 		}
 	}
-	cache.IsDirty = false
 
-	// If the nodes grows beyond the 200 entries we simple empty it
-	// FIXME come up with something better
-	if len(cache.nodes) > 200 {
-		cache.nodes = make(map[string]*Node)
-	}
+	batch.OnWrite(func() {
+		for _, node := range queued {
+			node.Dirty = false
+		}
+		// Nodes may have been added since the batch was queued
+		cache.IsDirty = false
+		for _, node := range cache.nodes {
+			if node.Dirty {
+				cache.IsDirty = true
+				break
+			}
+		}
+
+		// If the nodes grows beyond the 200 entries we simple empty it
+		// FIXME come up with something better
+		if !cache.IsDirty && len(cache.nodes) > 200 {
+			cache.nodes = make(map[string]*Node)
+		}
+	})
+
+	return nil
 }
 
 func (cache *Cache) Undo() {
@@ -260,9 +295,26 @@ func (t *Trie) Copy() *Trie {
 }
 
 // Save the cached value to the database.
-func (t *Trie) Sync() {
-	t.cache.Commit()
+func (t *Trie) Sync() error {
+	if err := t.cache.Commit(); err != nil {
+		return err
+	}
 	t.prevRoot = copyRoot(t.Root)
+
+	return nil
+}
+
+// Same as Sync but the cached values are added to the given batch instead
+// of being written to the database directly. The values count as saved once
+// the batch was written.
+func (t *Trie) SyncTo(batch ethutil.Batch) error {
+	if err := t.cache.CommitTo(batch); err != nil {
+		return err
+	}
+	root := copyRoot(t.Root)
+	batch.OnWrite(func() { t.prevRoot = root })
+
+	return nil
 }
 
 func (t *Trie) Undo() {
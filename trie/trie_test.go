@@ -31,8 +31,9 @@ func NewMemDatabase() (*MemDatabase, error) {
 	db := &MemDatabase{db: make(map[string][]byte)}
 	return db, nil
 }
-func (db *MemDatabase) Put(key []byte, value []byte) {
+func (db *MemDatabase) Put(key []byte, value []byte) error {
 	db.db[string(key)] = value
+	return nil
 }
 func (db *MemDatabase) Get(key []byte) ([]byte, error) {
 	return db.db[string(key)], nil
@@ -41,9 +42,35 @@ func (db *MemDatabase) Delete(key []byte) error {
 	delete(db.db, string(key))
 	return nil
 }
+func (db *MemDatabase) NewBatch() ethutil.Batch {
+	return &memBatch{db: db, writes: make(map[string][]byte)}
+}
 func (db *MemDatabase) Print()              {}
 func (db *MemDatabase) Close()              {}
 func (db *MemDatabase) LastKnownTD() []byte { return nil }
+func (db *MemDatabase) NewIterator(r *ethutil.Range) ethutil.Iterator {
+	return ethutil.NewMemIterator(db.db, r)
+}
+
+type memBatch struct {
+	ethutil.BatchHooks
+	db     *MemDatabase
+	writes map[string][]byte
+}
+
+func (b *memBatch) Put(key, value []byte) error { b.writes[string(key)] = value; return nil }
+func (b *memBatch) Delete(key []byte) error     { b.writes[string(key)] = nil; return nil }
+func (b *memBatch) Write() error {
+	for k, v := range b.writes {
+		if v == nil {
+			delete(b.db.db, k)
+		} else {
+			b.db.db[k] = v
+		}
+	}
+	b.Written()
+	return nil
+}
 
 func NewTrie() (*MemDatabase, *Trie) {
 	db, _ := NewMemDatabase()
@@ -61,6 +88,85 @@ func (s *TrieSuite) TestTrieSync(c *checker.C) {
 	c.Assert(s.db.db, checker.HasLen, 3)
 }
 
+func (s *TrieSuite) TestTrieSyncTo(c *checker.C) {
+	s.trie.Update("dog", LONG_WORD)
+	batch := s.db.NewBatch()
+	s.trie.SyncTo(batch)
+	c.Assert(s.db.db, checker.HasLen, 0, checker.Commentf("Expected no data in database before batch write"))
+	c.Assert(s.trie.cache.IsDirty, checker.Equals, true, checker.Commentf("Expected cache to stay dirty until the batch is written"))
+
+	batch.Write()
+	c.Assert(s.db.db, checker.HasLen, 3)
+	c.Assert(s.trie.cache.IsDirty, checker.Equals, false)
+}
+
+func (s *TrieSuite) TestTrieSyncToFailedWrite(c *checker.C) {
+	s.trie.Update("dog", LONG_WORD)
+
+	// The batch is dropped as if its write failed, the nodes must be queued again
+	s.trie.SyncTo(s.db.NewBatch())
+	batch := s.db.NewBatch()
+	s.trie.SyncTo(batch)
+	batch.Write()
+	c.Assert(s.db.db, checker.HasLen, 3)
+}
+
+func (s *TrieSuite) TestTrieWalk(c *checker.C) {
+	s.trie.Update("dog", LONG_WORD)
+	s.trie.Update("doge", LONG_WORD)
+	s.trie.Update("horse", "stallion")
+	s.trie.Sync()
+
+	nodes := make(map[string]bool)
+	var values []string
+	err := Walk(s.db, s.trie.GetRoot(), func(hash []byte) bool {
+		nodes[string(hash)] = true
+		return true
+	}, func(value *ethutil.Value) {
+		values = append(values, value.Str())
+	})
+	c.Assert(err, checker.IsNil)
+	c.Assert(nodes, checker.HasLen, len(s.db.db))
+	c.Assert(values, checker.HasLen, 3)
+
+	delete(s.db.db, string(s.trie.GetRoot()))
+	err = Walk(s.db, s.trie.GetRoot(), func([]byte) bool { return true }, nil)
+	c.Assert(err, checker.NotNil)
+}
+
+func (s *TrieSuite) TestTrieNodeSync(c *checker.C) {
+	s.trie.Update("dog", LONG_WORD)
+	s.trie.Update("doge", LONG_WORD)
+	s.trie.Update("horse", "stallion")
+	s.trie.Sync()
+
+	db, _ := NewMemDatabase()
+	var values int
+	sync := NewNodeSync(db)
+	sync.AddSubTrie(s.trie.GetRoot(), func(*ethutil.Value) { values++ })
+
+	for hashes := sync.Missing(1); len(hashes) > 0; hashes = sync.Missing(1) {
+		data, _ := s.db.Get(hashes[0])
+		n, err := sync.Process([][]byte{data})
+		c.Assert(err, checker.IsNil)
+		c.Assert(n, checker.Equals, 1)
+	}
+	c.Assert(sync.Pending(), checker.Equals, 0)
+	c.Assert(values, checker.Equals, 3)
+	c.Assert(db.db, checker.DeepEquals, s.db.db)
+	c.Assert(New(db, s.trie.GetRoot()).Get("doge"), checker.Equals, LONG_WORD)
+
+	_, err := sync.Process([][]byte{[]byte("not requested")})
+	c.Assert(err, checker.Equals, ErrNotRequested)
+
+	// Nodes in the database are walked instead of requested again
+	values = 0
+	sync = NewNodeSync(db)
+	sync.AddSubTrie(s.trie.GetRoot(), func(*ethutil.Value) { values++ })
+	c.Assert(sync.Pending(), checker.Equals, 0)
+	c.Assert(values, checker.Equals, 3)
+}
+
 func (s *TrieSuite) TestTrieDirtyTracking(c *checker.C) {
 	s.trie.Update("dog", LONG_WORD)
 	c.Assert(s.trie.cache.IsDirty, checker.Equals, true, checker.Commentf("Expected no data in database"))
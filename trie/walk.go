@@ -0,0 +1,69 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/georzaza/go-ethereum-v0.7.10_official/crypto"
+	"github.com/georzaza/go-ethereum-v0.7.10_official/ethutil"
+)
+
+// Walks the nodes of the trie with the given root straight from the
+// database, bypassing the cache.
+//
+// onNode is called with the hash of every node which is stored in the
+// database before the node is loaded. Returning false skips the node and
+// everything below it, which allows callers to avoid walking shared sub
+// tries twice. onValue (may be nil) is called with every value of the trie.
+//
+// Returns an error if a node is missing from the database.
+func Walk(db ethutil.Database, root interface{}, onNode func(hash []byte) bool, onValue func(value *ethutil.Value)) error {
+	return walk(db, ethutil.NewValue(root), onNode, onValue)
+}
+
+var emptyRoot = crypto.Sha3(ethutil.Encode(""))
+
+func walk(db ethutil.Database, node *ethutil.Value, onNode func([]byte) bool, onValue func(*ethutil.Value)) error {
+	// Resolve references to nodes which are stored by their hash
+	if node.Get(0).IsNil() {
+		ref := node.Bytes()
+		if len(ref) == 0 || bytes.Equal(ref, emptyRoot) {
+			return nil
+		}
+
+		if len(ref) < 32 {
+			node = ethutil.NewValueFromBytes(ref)
+		} else {
+			if !onNode(ref) {
+				return nil
+			}
+
+			data, _ := db.Get(ref)
+			if len(data) == 0 {
+				return fmt.Errorf("trie: missing node %x", ref)
+			}
+			node = ethutil.NewValueFromBytes(data)
+		}
+	}
+
+	switch getType(node) {
+	case LeafNode:
+		if onValue != nil {
+			onValue(node.Get(1))
+		}
+	case ExtNode:
+		return walk(db, node.Get(1), onNode, onValue)
+	case BranchNode:
+		for i := 0; i < 16; i++ {
+			if err := walk(db, node.Get(i), onNode, onValue); err != nil {
+				return err
+			}
+		}
+
+		if value := node.Get(16); value.Len() > 0 && onValue != nil {
+			onValue(value)
+		}
+	}
+
+	return nil
+}
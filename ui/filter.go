@@ -42,6 +42,18 @@ func NewFilterFromMap(object map[string]interface{}, eth core.EthManager) *core.
 		filter.Altered = makeAltered(object["altered"])
 	}
 
+	if object["address"] != nil {
+		filter.SetAddress(makeBytesSet(object["address"]))
+	}
+
+	if slice, ok := object["topics"].([]interface{}); ok {
+		topics := make([][][]byte, len(slice))
+		for i, item := range slice {
+			topics[i] = makeBytesSet(item)
+		}
+		filter.SetTopics(topics)
+	}
+
 	return filter
 }
 
@@ -73,3 +85,17 @@ func makeAltered(v interface{}) (d []core.AccountChange) {
 
 	return
 }
+
+// data can come in in the following formats:
+// "aabbcc", ["aabbcc", "ddeeff"] or null (the empty set)
+func makeBytesSet(v interface{}) (d [][]byte) {
+	if str, ok := v.(string); ok {
+		d = append(d, ethutil.Hex2Bytes(str))
+	} else if slice, ok := v.([]interface{}); ok {
+		for _, item := range slice {
+			d = append(d, makeBytesSet(item)...)
+		}
+	}
+
+	return
+}
@@ -22,6 +22,7 @@ type Server struct {
 	doneCh    chan bool
 	errCh     chan error
 	msgFunc   MsgFunc
+	closeFunc func(c *Client)
 }
 
 // Create new chat server.
@@ -44,6 +45,7 @@ func NewServer(pattern, httpServ string) *Server {
 		doneCh,
 		errCh,
 		nil,
+		nil,
 	}
 }
 
@@ -78,6 +80,12 @@ func (s *Server) MessageFunc(f MsgFunc) {
 	s.msgFunc = f
 }
 
+// Sets the function called once a client disconnected, to release whatever
+// was set up for it.
+func (s *Server) CloseFunc(f func(c *Client)) {
+	s.closeFunc = f
+}
+
 // Listen and serve.
 // It serves client connection and broadcast request.
 func (s *Server) Listen() {
@@ -115,8 +123,17 @@ func (s *Server) Listen() {
 
 		// del a client
 		case c := <-s.delCh:
+			// A client is deleted by both its read and write loop
+			if s.clients[c.id] == nil {
+				break
+			}
 			delete(s.clients, c.id)
 
+			// Not called on this loop, the function may end up writing to clients
+			if s.closeFunc != nil {
+				go s.closeFunc(c)
+			}
+
 		case err := <-s.errCh:
 			wslogger.Debugln("Error:", err.Error())
 
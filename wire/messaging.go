@@ -40,6 +40,8 @@ const (
 	MsgGetBlocksTy      = 0x15
 	MsgBlockTy          = 0x16
 	MsgNewBlockTy       = 0x17
+	MsgGetNodeDataTy    = 0x18
+	MsgNodeDataTy       = 0x19
 )
 
 var msgTypeToString = map[MsgType]string{
@@ -56,6 +58,8 @@ var msgTypeToString = map[MsgType]string{
 	MsgGetBlockHashesTy: "Get block hashes",
 	MsgBlockHashesTy:    "Block hashes",
 	MsgGetBlocksTy:      "Get blocks",
+	MsgGetNodeDataTy:    "Get node data",
+	MsgNodeDataTy:       "Node data",
 }
 
 func (mt MsgType) String() string {
@@ -3,6 +3,7 @@ package xeth
 import (
 	"bytes"
 	"encoding/json"
+	"math/big"
 	"sync/atomic"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
@@ -47,6 +48,87 @@ func (self *JSXEth) Block(v interface{}) *JSBlock {
 	return nil
 }
 
+// Returns the mined transaction with the given hash, nil if no block of the
+// canonical chain includes it.
+func (self *JSXEth) Transaction(strHash string) *JSTransaction {
+	chainMan := self.obj.ChainManager()
+
+	tx, block, _ := chainMan.GetTransaction(ethutil.Hex2Bytes(strHash))
+	if tx == nil {
+		return nil
+	}
+
+	return self.minedTx(tx, block)
+}
+
+// Returns the mined transaction with the given hash and its receipt, both
+// taken from the same block. The transaction is nil if no block of the
+// canonical chain includes it, the receipt is nil as well if no receipts are
+// stored for that block.
+func (self *JSXEth) TransactionReceipt(strHash string) (*JSTransaction, *JSTxReceipt) {
+	hash := ethutil.Hex2Bytes(strHash)
+
+	tx, receipt, block, index := self.obj.ChainManager().GetTransactionReceipt(hash)
+	if tx == nil {
+		return nil, nil
+	}
+
+	jstx := self.minedTx(tx, block)
+	if receipt == nil {
+		return jstx, nil
+	}
+
+	return jstx, NewJSTxReceipt(hash, block, index, receipt)
+}
+
+// Inner function that wraps a transaction included by the given block. The
+// head may lag behind the block while the chain is reorganised, there are no
+// confirmations then.
+func (self *JSXEth) minedTx(tx *types.Transaction, block *types.Block) *JSTransaction {
+	jstx := NewJSTx(tx, block.State())
+	if head := self.obj.ChainManager().CurrentBlock(); head.Number.Cmp(block.Number) >= 0 {
+		jstx.Confirmations = int(new(big.Int).Sub(head.Number, block.Number).Int64()) + 1
+	}
+
+	return jstx
+}
+
+// Returns the receipt of the mined transaction with the given hash, nil if no
+// block of the canonical chain includes it.
+func (self *JSXEth) Receipt(strHash string) *JSTxReceipt {
+	chainMan := self.obj.ChainManager()
+	hash := ethutil.Hex2Bytes(strHash)
+
+	receipt, block, index := chainMan.GetReceipt(hash)
+	if receipt == nil {
+		return nil
+	}
+
+	return NewJSTxReceipt(hash, block, index, receipt)
+}
+
+// Returns the blocks which were rejected as bad
+func (self *JSXEth) BadBlocks() []*JSBadBlock {
+	var blocks []*JSBadBlock
+	for _, bad := range self.obj.ChainManager().BadBlocks() {
+		blocks = append(blocks, NewJSBadBlock(bad))
+	}
+
+	return blocks
+}
+
+// Returns the pending and queued transactions of the transaction pool, grouped by sender
+func (self *JSXEth) TxPoolStatus() *JSTxPoolStatus {
+	status := &JSTxPoolStatus{Accounts: []*JSTxPoolAccount{}}
+	for _, account := range self.obj.TxPool().Inspect() {
+		status.Pending += len(account.Pending)
+		status.Queued += len(account.Queued)
+		status.Accounts = append(status.Accounts, NewJSTxPoolAccount(account))
+	}
+
+	return status
+}
+
 func (self *JSXEth) Key() *JSKey {
 	return NewJSKey(self.obj.KeyManager().KeyPair())
 }
@@ -253,3 +335,12 @@ func ToJSMessages(messages state.Messages) *ethutil.List {
 
 	return ethutil.NewList(msgs)
 }
+
+func ToJSLogs(logs state.Logs) *ethutil.List {
+	var jslogs []JSLog
+	for _, l := range logs {
+		jslogs = append(jslogs, NewJSLog(l))
+	}
+
+	return ethutil.NewList(jslogs)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core"
 	"github.com/georzaza/go-ethereum-v0.7.10_official/core/types"
@@ -205,6 +206,102 @@ func NewJSReciept(contractCreation bool, creationAddress, hash, address []byte)
 	}
 }
 
+// Receipt of a mined transaction
+type JSTxReceipt struct {
+	Hash              string        `json:"hash"`
+	BlockHash         string        `json:"blockHash"`
+	BlockNumber       int           `json:"blockNumber"`
+	Index             int           `json:"index"`
+	PostState         string        `json:"postState"`
+	CumulativeGasUsed string        `json:"cumulativeGasUsed"`
+	Logs              *ethutil.List `json:"logs"`
+}
+
+func NewJSTxReceipt(hash []byte, block *types.Block, index uint64, receipt *types.Receipt) *JSTxReceipt {
+	return &JSTxReceipt{
+		Hash:              ethutil.Bytes2Hex(hash),
+		BlockHash:         ethutil.Bytes2Hex(block.Hash()),
+		BlockNumber:       int(block.Number.Uint64()),
+		Index:             int(index),
+		PostState:         ethutil.Bytes2Hex(receipt.PostState),
+		CumulativeGasUsed: receipt.CumulativeGasUsed.String(),
+		Logs:              ToJSLogs(receipt.Logs()),
+	}
+}
+
+// A block which was rejected as bad, see core.BadBlock
+type JSBadBlock struct {
+	Hash       string `json:"hash"`
+	Number     int    `json:"number"`
+	ParentHash string `json:"parentHash"`
+	Reason     string `json:"reason"`
+}
+
+func NewJSBadBlock(bad *core.BadBlock) *JSBadBlock {
+	return &JSBadBlock{
+		Hash:       ethutil.Bytes2Hex(bad.Hash),
+		Number:     int(bad.Number.Uint64()),
+		ParentHash: ethutil.Bytes2Hex(bad.ParentHash),
+		Reason:     bad.Reason,
+	}
+}
+
+// A transaction of the transaction pool, see core.TxStatus. Age is the
+// number of seconds since the transaction was added to the pool.
+type JSTxStatus struct {
+	Hash     string `json:"hash"`
+	Nonce    int    `json:"nonce"`
+	GasPrice string `json:"gasPrice"`
+	Gas      string `json:"gas"`
+	Value    string `json:"value"`
+	Age      int    `json:"age"`
+	Local    bool   `json:"local"`
+}
+
+func NewJSTxStatus(status *core.TxStatus) *JSTxStatus {
+	return &JSTxStatus{
+		Hash:     ethutil.Bytes2Hex(status.Tx.Hash()),
+		Nonce:    int(status.Tx.Nonce()),
+		GasPrice: status.Tx.GasPrice().String(),
+		Gas:      status.Tx.Gas().String(),
+		Value:    status.Tx.Value().String(),
+		Age:      int(time.Since(status.Added).Seconds()),
+		Local:    status.Local,
+	}
+}
+
+// The transactions of a sender in the transaction pool, see core.TxPoolAccount
+type JSTxPoolAccount struct {
+	Address string        `json:"address"`
+	Nonce   int           `json:"nonce"`
+	Pending []*JSTxStatus `json:"pending"`
+	Queued  []*JSTxStatus `json:"queued"`
+}
+
+func NewJSTxPoolAccount(account *core.TxPoolAccount) *JSTxPoolAccount {
+	acc := &JSTxPoolAccount{
+		Address: ethutil.Bytes2Hex(account.Address),
+		Nonce:   int(account.Nonce),
+		Pending: make([]*JSTxStatus, len(account.Pending)),
+		Queued:  make([]*JSTxStatus, len(account.Queued)),
+	}
+	for i, status := range account.Pending {
+		acc.Pending[i] = NewJSTxStatus(status)
+	}
+	for i, status := range account.Queued {
+		acc.Queued[i] = NewJSTxStatus(status)
+	}
+
+	return acc
+}
+
+// The contents of the transaction pool, grouped by sender
+type JSTxPoolStatus struct {
+	Pending  int                `json:"pending"`
+	Queued   int                `json:"queued"`
+	Accounts []*JSTxPoolAccount `json:"accounts"`
+}
+
 type JSMessage struct {
 	To        string `json:"to"`
 	From      string `json:"from"`
@@ -234,3 +331,22 @@ func NewJSMessage(message *state.Message) JSMessage {
 		Value:     message.Value.String(),
 	}
 }
+
+type JSLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+func NewJSLog(log state.Log) JSLog {
+	var topics []string
+	for _, topic := range log.Topics() {
+		topics = append(topics, ethutil.Bytes2Hex(topic))
+	}
+
+	return JSLog{
+		Address: ethutil.Bytes2Hex(log.Address()),
+		Topics:  topics,
+		Data:    ethutil.Bytes2Hex(log.Data()),
+	}
+}
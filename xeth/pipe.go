@@ -143,7 +143,7 @@ func (self *XEth) Transact(key *crypto.KeyPair, to []byte, value, gas, price *et
 	coinbase.SetGasPool(block.GasLimit)
 	self.blockManager.ApplyTransactions(coinbase, state, block, types.Transactions{tx}, true)
 
-	err := self.obj.TxPool().Add(tx)
+	err := self.obj.TxPool().AddLocal(tx)
 	if err != nil {
 		return nil, err
 	}